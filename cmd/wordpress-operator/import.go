@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/bitpoke/wordpress-operator/pkg/apis"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/importer"
+)
+
+const importUsage = "Usage: wordpress-operator import [flags] DEPLOYMENT"
+
+var errImportUsage = errors.New(importUsage)
+
+// runImport generates a Wordpress resource matching an existing WordPress
+// deployment and prints it or, with --adopt, replaces the deployment with it.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	namespace := fs.StringP("namespace", "n", "default", "The namespace of the deployment.")
+	name := fs.String("name", "", "The name of the generated Wordpress. Defaults to the deployment name.")
+	adopt := fs.Bool("adopt", false, "Replaces the deployment, along with its services and ingresses, with the generated Wordpress.")
+
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, importUsage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errImportUsage
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	if err = apis.AddToScheme(scheme.Scheme); err != nil {
+		return err
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	site, err := importer.Inspect(ctx, c, *namespace, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *name != "" {
+		site.Wordpress.Name = *name
+	}
+
+	for _, w := range site.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+
+	if *adopt {
+		return site.Adopt(ctx, c)
+	}
+
+	serializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, json.SerializerOptions{Yaml: true})
+
+	return serializer.Encode(site.Wordpress, os.Stdout)
+}
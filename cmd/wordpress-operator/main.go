@@ -17,12 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
 
 	logf "github.com/presslabs/controller-util/log"
 	flag "github.com/spf13/pflag"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -31,6 +33,8 @@ import (
 	"github.com/bitpoke/wordpress-operator/pkg/apis"
 	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
 	"github.com/bitpoke/wordpress-operator/pkg/controller"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress"
+	"github.com/bitpoke/wordpress-operator/pkg/webhook"
 )
 
 const genericErrorExitCode = 1
@@ -40,6 +44,15 @@ var setupLog = logf.Log.WithName("wordpress-operator")
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(genericErrorExitCode)
+		}
+
+		return
+	}
+
 	options.AddToFlagSet(flag.CommandLine)
 	flag.Parse()
 
@@ -47,6 +60,18 @@ func main() {
 
 	setupLog.Info("Starting wordpress-operator...")
 
+	// each shard elects its own leader
+	leaderElectionID := options.LeaderElectionID
+	if options.Shards > 1 {
+		if options.Shard < 0 || options.Shard >= options.Shards {
+			setupLog.Error(nil, "the shard must be between 0 and shards-1", "shard", options.Shard, "shards", options.Shards)
+			os.Exit(genericErrorExitCode)
+		}
+
+		leaderElectionID = fmt.Sprintf("%s-shard-%d", leaderElectionID, options.Shard)
+		setupLog.Info("Reconciling a partition of the sites", "shard", options.Shard, "shards", options.Shards)
+	}
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -54,14 +79,22 @@ func main() {
 		os.Exit(genericErrorExitCode)
 	}
 
+	var newCache cache.NewCacheFunc
+	if options.CacheManagedObjectsOnly {
+		newCache = cache.BuilderWithOptions(cache.Options{SelectorsByObject: wordpress.CacheSelectors()})
+	}
+
 	// Create a new Cmd to provide shared dependencies and start components
 	mgr, err := manager.New(cfg, manager.Options{
+		NewCache:                   newCache,
 		LeaderElection:             options.LeaderElection,
-		LeaderElectionID:           options.LeaderElectionID,
+		LeaderElectionID:           leaderElectionID,
 		LeaderElectionNamespace:    options.LeaderElectionNamespace,
 		LeaderElectionResourceLock: "leases",
 		MetricsBindAddress:         options.MetricsBindAddress,
 		HealthProbeBindAddress:     options.HealthProbeBindAddress,
+		Port:                       options.WebhookPort,
+		CertDir:                    options.WebhookCertDir,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to create a new manager")
@@ -80,6 +113,14 @@ func main() {
 		os.Exit(genericErrorExitCode)
 	}
 
+	// Setup all Webhooks
+	if options.WebhooksEnabled {
+		if err := webhook.AddToManager(mgr); err != nil {
+			setupLog.Error(err, "unable to setup webhooks")
+			os.Exit(genericErrorExitCode)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -9,8 +9,10 @@ require (
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.15.0
 	github.com/presslabs/controller-util v0.3.0
+	github.com/prometheus/client_golang v1.11.0
 	github.com/spf13/pflag v1.0.5
 	golang.org/x/net v0.0.0-20210428140749-89ef3d95e781
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 
 	// kubernetes
 	k8s.io/api v0.21.4
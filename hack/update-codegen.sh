@@ -0,0 +1,47 @@
+#!/usr/bin/env bash
+# Regenerates the typed clientset, listers and informers under pkg/client.
+set -o errexit
+set -o nounset
+set -o pipefail
+
+CODEGEN_VERSION=${CODEGEN_VERSION:-v0.21.4}
+MODULE=github.com/bitpoke/wordpress-operator
+APIS=${MODULE}/pkg/apis/wordpress/v1alpha1
+OUTPUT_PKG=${MODULE}/pkg/client
+
+ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+WORK_DIR=$(mktemp -d)
+trap 'rm -rf "${WORK_DIR}"' EXIT
+
+GOBIN="${WORK_DIR}/bin" go install \
+    k8s.io/code-generator/cmd/client-gen@"${CODEGEN_VERSION}" \
+    k8s.io/code-generator/cmd/lister-gen@"${CODEGEN_VERSION}" \
+    k8s.io/code-generator/cmd/informer-gen@"${CODEGEN_VERSION}"
+
+HEADER="${ROOT}/hack/boilerplate.go.txt"
+OUT="${WORK_DIR}/src"
+
+"${WORK_DIR}/bin/client-gen" \
+    --go-header-file "${HEADER}" \
+    --clientset-name versioned \
+    --input-base "" \
+    --input "${APIS}" \
+    --output-package "${OUTPUT_PKG}/clientset" \
+    --output-base "${OUT}"
+
+"${WORK_DIR}/bin/lister-gen" \
+    --go-header-file "${HEADER}" \
+    --input-dirs "${APIS}" \
+    --output-package "${OUTPUT_PKG}/listers" \
+    --output-base "${OUT}"
+
+"${WORK_DIR}/bin/informer-gen" \
+    --go-header-file "${HEADER}" \
+    --input-dirs "${APIS}" \
+    --versioned-clientset-package "${OUTPUT_PKG}/clientset/versioned" \
+    --listers-package "${OUTPUT_PKG}/listers" \
+    --output-package "${OUTPUT_PKG}/informers" \
+    --output-base "${OUT}"
+
+rm -rf "${ROOT}/pkg/client"
+cp -r "${OUT}/${OUTPUT_PKG}" "${ROOT}/pkg/client"
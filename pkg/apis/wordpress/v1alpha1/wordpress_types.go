@@ -18,7 +18,9 @@ package v1alpha1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -66,6 +68,105 @@ const (
 
 	// WPCronTriggeringReason is the reason for successfully triggering wp-cron.
 	WPCronTriggeringReason = "WPCronTriggering"
+
+	// DatabaseCredentialsReadyCondition signals whether the database credentials are available.
+	DatabaseCredentialsReadyCondition WordpressConditionType = "DatabaseCredentialsReady"
+
+	// DatabaseSecretNotFoundReason is the reason for the database credentials secret not being materialized yet.
+	DatabaseSecretNotFoundReason = "DatabaseSecretNotFound"
+
+	// DatabaseSecretReadyReason is the reason for the database credentials secret being available.
+	DatabaseSecretReadyReason = "DatabaseSecretReady"
+
+	// DBCredentialsRotationCondition signals the status of the database credentials rotation.
+	DBCredentialsRotationCondition WordpressConditionType = "DBCredentialsRotation"
+
+	// DBCredentialsRotatingReason is the reason for a database credentials rotation in progress.
+	DBCredentialsRotatingReason = "DBCredentialsRotating"
+
+	// DBCredentialsRotatedReason is the reason for successfully rotating the database credentials.
+	DBCredentialsRotatedReason = "DBCredentialsRotated"
+
+	// DBCredentialsRotationFailedReason is the reason for database credentials rotation failures.
+	DBCredentialsRotationFailedReason = "DBCredentialsRotationFailed"
+
+	// ReplicasConflictCondition signals that spec.replicas is ignored, as the
+	// deployment is scaled by an autoscaler.
+	ReplicasConflictCondition WordpressConditionType = "ReplicasConflict"
+
+	// HorizontalPodAutoscalerReason is the reason for the deployment being scaled by a HorizontalPodAutoscaler.
+	HorizontalPodAutoscalerReason = "HorizontalPodAutoscaler"
+
+	// NoReplicasConflictReason is the reason for spec.replicas being applied to the deployment.
+	NoReplicasConflictReason = "NoReplicasConflict"
+
+	// ImageResolvedCondition signals whether the image tag was resolved to a digest.
+	ImageResolvedCondition WordpressConditionType = "ImageResolved"
+
+	// ImageResolvedReason is the reason for successfully resolving the image tag.
+	ImageResolvedReason = "ImageResolved"
+
+	// ImageResolveFailedReason is the reason for image tag resolving failures.
+	ImageResolveFailedReason = "ImageResolveFailed"
+
+	// SiteHealthyCondition signals whether the Site Health tests report critical issues.
+	SiteHealthyCondition WordpressConditionType = "SiteHealthy"
+
+	// SiteHealthyReason is the reason for the Site Health tests not reporting critical issues.
+	SiteHealthyReason = "SiteHealthy"
+
+	// SiteHealthCriticalIssuesReason is the reason for the Site Health tests reporting critical issues.
+	SiteHealthCriticalIssuesReason = "SiteHealthCriticalIssues"
+
+	// SiteHealthCheckFailedReason is the reason for failures to run the Site Health tests.
+	SiteHealthCheckFailedReason = "SiteHealthCheckFailed"
+
+	// SiteHealthCredentialsMissingReason is the reason for the Site Health
+	// application password secret being missing or incomplete.
+	SiteHealthCredentialsMissingReason = "SiteHealthCredentialsMissing"
+
+	// ContentExportCondition signals the status of the WXR content export.
+	ContentExportCondition WordpressConditionType = "ContentExport"
+
+	// ContentImportCondition signals the status of the WXR content import.
+	ContentImportCondition WordpressConditionType = "ContentImport"
+
+	// ContentTransferRunningReason is the reason for a content export or import in progress.
+	ContentTransferRunningReason = "ContentTransferRunning"
+
+	// ContentTransferSucceededReason is the reason for a successful content export or import.
+	ContentTransferSucceededReason = "ContentTransferSucceeded"
+
+	// ContentTransferFailedReason is the reason for content export or import failures.
+	ContentTransferFailedReason = "ContentTransferFailed"
+
+	// VolumeClaimReleasedReason is the reason for keeping a volume claim which is no longer used by the site.
+	VolumeClaimReleasedReason = "VolumeClaimReleased"
+
+	// ResourcesSyncedCondition signals whether all the site resources were successfully reconciled.
+	ResourcesSyncedCondition WordpressConditionType = "ResourcesSynced"
+
+	// ResourcesSyncedReason is the reason for all the site resources being reconciled.
+	ResourcesSyncedReason = "ResourcesSynced"
+
+	// ResourcesSyncFailedReason is the reason for failures to reconcile some of the site resources.
+	ResourcesSyncFailedReason = "ResourcesSyncFailed"
+
+	// InvalidSpecReason is the reason for failures to reconcile the site resources, which
+	// are not retried until the spec changes.
+	InvalidSpecReason = "InvalidSpec"
+
+	// AdminUsersReadyCondition signals whether the admin users are reconciled.
+	AdminUsersReadyCondition WordpressConditionType = "AdminUsersReady"
+
+	// AdminUsersReconciledReason is the reason for all the admin users being reconciled.
+	AdminUsersReconciledReason = "AdminUsersReconciled"
+
+	// AdminUsersReconcilingReason is the reason for admin user jobs in progress.
+	AdminUsersReconcilingReason = "AdminUsersReconciling"
+
+	// AdminUserReconcileFailedReason is the reason for admin user job failures.
+	AdminUserReconcileFailedReason = "AdminUserReconcileFailed"
 )
 
 // WordpressSpec defines the desired state of Wordpress.
@@ -74,6 +175,9 @@ type WordpressSpec struct {
 	// explicit zero and not specified. Defaults to 1.
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
+	// Autoscaling configures the autoscaling of the web deployment
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
 	// Domains for which this this site answers.
 	// The first item is set as the "main domain" (eg. WP_HOME and WP_SITEURL constants).
 	// Deprecated: use Routes instead. This field will be dropped in next release.
@@ -84,13 +188,41 @@ type WordpressSpec struct {
 	// If no routes are specified, ingress syncing is disabled and WP_HOME de defaults to NAME.NAMESPACE.svc.
 	// +optional
 	Routes []RouteSpec `json:"routes,omitempty"`
+	// CommonLabels are applied to all the objects created for the site (eg.
+	// for cost allocation, backup selection or policies).
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	// CommonAnnotations are applied to all the objects created for the site.
+	// +optional
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
 	// WordPress runtime image to use. Defaults to docker.io/bitpoke/wordpress-runtime:<latest stable runtime tag>
 	// +optional
 	Image string `json:"image,omitempty"`
+	// PHPVersion selects the runtime image built for the given PHP version, by
+	// setting the -phpXY suffix of the image tag (eg. 5.8.2-php80).
+	// +kubebuilder:validation:Enum="7.3";"7.4";"8.0"
+	// +optional
+	PHPVersion string `json:"phpVersion,omitempty"`
+	// PHPConfig defines php.ini directives (eg. memory_limit,
+	// upload_max_filesize), which are rendered as-is into a ConfigMap mounted
+	// as an additional conf.d file. The pods are rolled out when they change.
+	// +optional
+	PHPConfig map[string]string `json:"phpConfig,omitempty"`
+	// OPcache tunes the PHP OPcache. The settings are rendered into the php
+	// config, where the phpConfig directives take precedence.
+	// +optional
+	OPcache *OPcacheSpec `json:"opcache,omitempty"`
+	// Cache configures the page caching done by the web pods.
+	// +optional
+	Cache *CacheSpec `json:"cache,omitempty"`
 	// ImagePullPolicy overrides WordpressRuntime spec.imagePullPolicy
 	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
 	// +optional
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePolicy defines how the image tag is resolved to a digest, which
+	// gets pinned in the web deployment and the jobs.
+	// +optional
+	ImagePolicy *ImagePolicySpec `json:"imagePolicy,omitempty"`
 	// ImagePullSecrets defines additional secrets to use when pulling images
 	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
 	// ServiceAccountName is the name of the ServiceAccount to use to run this
@@ -101,8 +233,29 @@ type WordpressSpec struct {
 	// TLSSecretRef a secret containing the TLS certificates for this site.
 	// +optional
 	TLSSecretRef SecretRef `json:"tlsSecretRef,omitempty"`
-	// DeploymentStrategy allows setting the deployment strategy for the WordPress site
+	// DeploymentStrategy allows setting the deployment strategy for the WordPress site.
+	// Use Recreate for sites using ReadWriteOnce code or media volumes, or tune
+	// RollingUpdate maxSurge and maxUnavailable for large sites.
+	// Defaults to RollingUpdate.
+	// +optional
 	DeploymentStrategy *appsv1.DeploymentStrategy `json:"deploymentStrategy,omitempty"`
+	// RevisionHistoryLimit is the number of old ReplicaSets kept for rolling
+	// back the web deployment. Defaults to the operator --revision-history-limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// MinReadySeconds is the minimum number of seconds for which a newly
+	// created web pod should be ready before being considered available.
+	// Defaults to the operator --min-ready-seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+	// ProgressDeadlineSeconds is the maximum time in seconds for the web
+	// deployment rollout to make progress before it is considered failed.
+	// Defaults to the operator --progress-deadline-seconds.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
 	// CodeVolumeSpec specifies how the site's code gets mounted into the
 	// container. If not specified, a code volume won't get mounted at all.
 	// +optional
@@ -125,13 +278,29 @@ type WordpressSpec struct {
 	// If not specified, a default probe that makes a HTTP request on the "/-/php-ping" path will be used.
 	// +optional
 	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// Database specifies how the database credentials are provided to the site.
+	// +optional
+	Database *DatabaseSpec `json:"database,omitempty"`
 	// WordpressBootstrapSpec specifies credentials used to install wordpress, on the first run.
 	// +optional
 	WordpressBootstrapSpec *WordpressBootstrapSpec `json:"bootstrap,omitempty"`
+	// AdminUsers are WordPress users created and kept up to date by wp-cli
+	// jobs. Users removed from the list are not deleted from WordPress.
+	// +optional
+	AdminUsers []AdminUserSpec `json:"adminUsers,omitempty"`
 	// WordpressPathPrefix is the path prefix under which wordpress is available.
 	// It defaults to /wp.
 	// +optional
 	WordpressPathPrefix string `json:"wordpressPathPrefix,omitempty"`
+	// ManagedWPCron specifies if wp-cron is triggered by the operator. When
+	// enabled, DISABLE_WP_CRON=true is set in the site env, so that wp-cron
+	// doesn't also run on page loads. Defaults to true.
+	// +optional
+	ManagedWPCron *bool `json:"managedWPCron,omitempty"`
+	// WPCronInterval is the interval at which wp-cron is triggered by the
+	// operator. Defaults to 30s. Shorter intervals than 10s are raised to 10s.
+	// +optional
+	WPCronInterval *metav1.Duration `json:"wpCronInterval,omitempty"`
 	// VolumeMountsSpec defines additional mounts which get injected into web
 	// and cli pods.
 	// +optional
@@ -157,18 +326,88 @@ type WordpressSpec struct {
 	// If specified, the pod's scheduling constraints
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Components overrides the pod settings for the web and the wp-cli job pods.
+	// wp-cron is triggered over HTTP by the operator, so it doesn't run any pods.
+	// +optional
+	Components *ComponentsSpec `json:"components,omitempty"`
 	// If specified, indicates the pod's priority class
 	// +optional
 	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// TerminationGracePeriodSeconds is the time, in seconds, the web and job
+	// pods are given for stopping gracefully. Defaults to the operator
+	// --termination-grace-period-seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// HostAliases are added to the hosts file of the web and job pods, for
+	// resolving sibling services or legacy hostnames (eg. pointing the site
+	// domain to the cluster service during migrations).
+	// +optional
+	// +patchMergeKey=ip
+	// +patchStrategy=merge
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty" patchStrategy:"merge" patchMergeKey:"ip"`
+	// DNSPolicy is the DNS policy of the web and job pods. Defaults to ClusterFirst.
+	// +kubebuilder:validation:Enum=ClusterFirstWithHostNet;ClusterFirst;Default;None
+	// +optional
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+	// DNSConfig specifies additional nameservers, search domains and resolver
+	// options for the web and job pods (eg. for resolving the database host
+	// through external resolvers).
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+	// SeccompProfile is the seccomp profile applied to web and cli pods.
+	// Defaults to RuntimeDefault.
+	// +optional
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+	// AppArmorProfile is the AppArmor profile applied to all the containers of
+	// web and cli pods. It can be one of runtime/default, localhost/<profile>
+	// or unconfined. Defaults to runtime/default.
+	// +optional
+	AppArmorProfile string `json:"appArmorProfile,omitempty"`
 	// IngressAnnotations for this Wordpress site
 	// +optional
 	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	// Routing configures how the requests are routed to the web pods
+	// +optional
+	Routing *RoutingSpec `json:"routing,omitempty"`
+	// Service configures how the web Service is exposed
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
 	// Additional init containers
 	// +optional
 	InitContainers []corev1.Container `json:"initContainers,omitempty"`
 	// Additional sidecar containers (eg. blackfire or tideways agent)
 	// +optional
 	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// Logging configures how the site logs are collected.
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+	// Metrics configures the collection of the runtime metrics.
+	// +optional
+	Metrics *MetricsSpec `json:"metrics,omitempty"`
+	// MediaGC enables a periodic job which detects the orphaned media files
+	// (files in the uploads directory not belonging to any attachment).
+	// +optional
+	MediaGC *MediaGCSpec `json:"mediaGC,omitempty"`
+	// DBMaintenance enables a periodic job which deletes the expired
+	// transients and optimizes the database tables.
+	// +optional
+	DBMaintenance *DBMaintenanceSpec `json:"dbMaintenance,omitempty"`
+	// SiteHealth periodically runs the WordPress Site Health tests exposed
+	// through the REST API and summarizes their results into the status.
+	// +optional
+	SiteHealth *SiteHealthSpec `json:"siteHealth,omitempty"`
+	// DeveloperAccess configures the ways developers can access the site code
+	// without kubectl exec.
+	// +optional
+	DeveloperAccess *DeveloperAccessSpec `json:"developerAccess,omitempty"`
+	// ExtraResources are additional namespaced objects created along the
+	// site. Each item is a YAML manifest rendered as a Go template, with the
+	// site as data (eg. {{ .Name }}, {{ .MainDomain }}). The objects are owned by
+	// the site and get deleted once removed from the list. Only the kinds
+	// allowed by the operator (--extra-resource-kinds) can be created.
+	// +optional
+	ExtraResources []string `json:"extraResources,omitempty"`
 }
 
 // GitVolumeSource is the desired spec for git code source.
@@ -192,6 +431,70 @@ type GitVolumeSource struct {
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
 }
 
+// OCIVolumeSource is the desired spec for pulling the code from an OCI
+// artifact stored in a container registry.
+type OCIVolumeSource struct {
+	// Image is the reference of the OCI artifact holding the code (eg.
+	// ghcr.io/example/site-code:v1.2.3). Reference it by digest for
+	// immutable deployments.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+	// SubPath is the path within the artifact where the code is located.
+	// Defaults to the artifact root.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+	// Env defines env variables which get passed to the OCI pull container.
+	// Taken into account are: OCI_USERNAME, OCI_PASSWORD
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// EnvFrom defines envFrom which get passed to the OCI pull container
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// EmptyDir volume to unpack the artifact into.
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
+// ObjectStorageProvider is the object storage service holding the code archive.
+// +kubebuilder:validation:Enum=s3;gcs
+type ObjectStorageProvider string
+
+const (
+	// S3ObjectStorageProvider downloads the archive from an S3 compatible object store.
+	S3ObjectStorageProvider ObjectStorageProvider = "s3"
+	// GCSObjectStorageProvider downloads the archive from Google Cloud Storage.
+	GCSObjectStorageProvider ObjectStorageProvider = "gcs"
+)
+
+// ObjectStorageVolumeSource is the desired spec for downloading the code from
+// a tarball stored in an object storage bucket.
+type ObjectStorageVolumeSource struct {
+	// Provider is the object storage service, either s3 or gcs
+	Provider ObjectStorageProvider `json:"provider"`
+	// Bucket holding the code archive
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+	// Key of the code archive within the bucket. Both .tar and .tar.gz
+	// archives are supported.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// Env variables for accessing the bucket. Taken into account are:
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION, S3_ENDPOINT for
+	// s3 and GOOGLE_CREDENTIALS for gcs
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// EnvFrom defines envFrom which get passed to the download container
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// EmptyDir volume to extract the archive into.
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
 // S3VolumeSource is the desired spec for accessing media files over S3
 // compatible object store.
 type S3VolumeSource struct {
@@ -206,6 +509,12 @@ type S3VolumeSource struct {
 	// +patchMergeKey=name
 	// +patchStrategy=merge
 	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// IAMRole is the ARN of the IAM role used for accessing the bucket through
+	// IAM Roles for Service Accounts (IRSA). If set and no ServiceAccountName
+	// is specified, the site pods run under a dedicated ServiceAccount
+	// annotated with this role, and the static access keys are omitted.
+	// +optional
+	IAMRole string `json:"iamRole,omitempty"`
 }
 
 // GCSVolumeSource is the desired spec for accessing media files using google
@@ -222,6 +531,13 @@ type GCSVolumeSource struct {
 	// +patchMergeKey=name
 	// +patchStrategy=merge
 	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// ServiceAccount is the email of the Google service account used for
+	// accessing the bucket through GKE Workload Identity. If set and no
+	// ServiceAccountName is specified, the site pods run under a dedicated
+	// ServiceAccount bound to this service account, and the static
+	// credentials are omitted.
+	// +optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
 }
 
 // CodeVolumeSpec is the desired spec for mounting code into the wordpress
@@ -248,9 +564,17 @@ type CodeVolumeSpec struct {
 	// level of precedence over EmptyDir, HostPath and PersistentVolumeClaim
 	// +optional
 	GitDir *GitVolumeSource `json:"git,omitempty"`
+	// OCI specifies the OCI artifact to unpack the code from. It takes
+	// precedence over EmptyDir, HostPath and PersistentVolumeClaim
+	// +optional
+	OCI *OCIVolumeSource `json:"oci,omitempty"`
+	// ObjectStorage specifies the code archive to download from S3 or GCS.
+	// It takes precedence over EmptyDir, HostPath and PersistentVolumeClaim
+	// +optional
+	ObjectStorage *ObjectStorageVolumeSource `json:"objectStorage,omitempty"`
 	// PersistentVolumeClaim to use if no GitDir is specified
 	// +optional
-	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
+	PersistentVolumeClaim *CodePersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
 	// HostPath to use if no PersistentVolumeClaim is specified
 	// +optional
 	HostPath *corev1.HostPathVolumeSource `json:"hostPath,omitempty"`
@@ -259,6 +583,17 @@ type CodeVolumeSpec struct {
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
 }
 
+// CodePersistentVolumeClaimSpec is the desired spec for the code volume claim.
+type CodePersistentVolumeClaimSpec struct {
+	// ClaimName references an existing claim to use instead of creating one
+	// for the site (eg. a ReadWriteMany claim sharing the code between
+	// multiple sites). The referenced claim is not managed by the operator.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	corev1.PersistentVolumeClaimSpec `json:",inline"`
+}
+
 // MediaVolumeSpec is the desired spec for handling media files at runtime.
 type MediaVolumeSpec struct {
 	// Metadata for the media volume. Currently only labels and annotations are set if a PVC is specified
@@ -283,6 +618,11 @@ type MediaVolumeSpec struct {
 	// over EmptyDir, HostPath and PersistentVolumeClaim
 	// +optional
 	GCSVolumeSource *GCSVolumeSource `json:"gcs,omitempty"`
+	// ProvisionBucket specifies if the S3 or GCS bucket should be provisioned
+	// by the operator, using Crossplane for S3 and Config Connector for GCS.
+	// Provisioned buckets are not deleted along with the site.
+	// +optional
+	ProvisionBucket bool `json:"provisionBucket,omitempty"`
 	// PersistentVolumeClaim to use if no S3VolumeSource or GCSVolumeSource are
 	// specified
 	// +optional
@@ -295,6 +635,618 @@ type MediaVolumeSpec struct {
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
 }
 
+// ComponentsSpec defines the pod settings overrides for the site components.
+type ComponentsSpec struct {
+	// Web overrides the settings of the web pods
+	// +optional
+	Web *ComponentSpec `json:"web,omitempty"`
+	// Jobs overrides the settings of the wp-cli job pods
+	// +optional
+	Jobs *ComponentSpec `json:"jobs,omitempty"`
+	// Cron overrides the settings of the scheduled job pods (eg. the media
+	// garbage collection), on top of the jobs ones
+	// +optional
+	Cron *ComponentSpec `json:"cron,omitempty"`
+}
+
+// ComponentSpec defines the pod settings overrides for a component. The
+// settings take precedence over the site wide ones.
+type ComponentSpec struct {
+	// Resources of the wordpress container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Labels added to the pods
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations added to the pods
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// NodeSelector of the pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations of the pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// AutoscalingSpec defines the autoscaling of the web deployment.
+type AutoscalingSpec struct {
+	// Vertical configures a VerticalPodAutoscaler for the web deployment
+	// +optional
+	Vertical *VerticalAutoscalingSpec `json:"vertical,omitempty"`
+	// KEDA configures a KEDA ScaledObject for the web deployment. When set,
+	// spec.replicas is ignored.
+	// +optional
+	KEDA *KEDAAutoscalingSpec `json:"keda,omitempty"`
+}
+
+// VerticalAutoscalingSpec defines the VerticalPodAutoscaler of the web deployment.
+type VerticalAutoscalingSpec struct {
+	// UpdateMode controls whether the recommended resources are applied to the
+	// pods. Off only computes recommendations, Initial applies them when pods
+	// are created and Auto also evicts running pods. Defaults to Off.
+	// +kubebuilder:validation:Enum=Off;Initial;Auto
+	// +optional
+	UpdateMode string `json:"updateMode,omitempty"`
+	// MinAllowed is the lower limit of the resources recommended for the wordpress container
+	// +optional
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+	// MaxAllowed is the upper limit of the resources recommended for the wordpress container
+	// +optional
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+}
+
+// KEDAAutoscalingSpec defines the KEDA ScaledObject of the web deployment.
+type KEDAAutoscalingSpec struct {
+	// MinReplicas is the minimum number of web pods. Setting it to 0 enables
+	// scaling to zero, for dormant sites. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the maximum number of web pods.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// PollingInterval is the interval, in seconds, at which the triggers are checked.
+	// +optional
+	PollingInterval *int32 `json:"pollingInterval,omitempty"`
+	// CooldownPeriod is the period, in seconds, to wait after the last
+	// active trigger before scaling to zero.
+	// +optional
+	CooldownPeriod *int32 `json:"cooldownPeriod,omitempty"`
+	// Triggers activate the scaling of the web deployment
+	// +kubebuilder:validation:MinItems=1
+	Triggers []KEDATrigger `json:"triggers"`
+}
+
+// KEDATrigger defines a KEDA scaler (eg. requests per second from Prometheus
+// or queue depth).
+type KEDATrigger struct {
+	// Type is the type of the scaler (eg. prometheus, rabbitmq, aws-sqs-queue)
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+	// Metadata is the scaler configuration
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// AuthenticationRef is the name of the KEDA TriggerAuthentication used by the scaler
+	// +optional
+	AuthenticationRef string `json:"authenticationRef,omitempty"`
+}
+
+// OPcacheSpec defines the PHP OPcache settings.
+type OPcacheSpec struct {
+	// Profile is a preset of the revalidation settings. The dev profile checks
+	// the scripts for changes on every request, which suits code stored on a
+	// volume and edited in place, while the prod profile never checks them,
+	// which suits immutable code (eg. cloned from git or baked in the image).
+	// +kubebuilder:validation:Enum=dev;prod
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// MemoryConsumption is the size of the shared memory used by OPcache, in megabytes.
+	// +kubebuilder:validation:Minimum=8
+	// +optional
+	MemoryConsumption *int32 `json:"memoryConsumption,omitempty"`
+	// MaxAcceleratedFiles is the maximum number of scripts kept in the cache.
+	// +kubebuilder:validation:Minimum=200
+	// +optional
+	MaxAcceleratedFiles *int32 `json:"maxAcceleratedFiles,omitempty"`
+	// RevalidateFreq is how often, in seconds, the scripts are checked for
+	// changes. Setting it enables the timestamps validation, regardless of the profile.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevalidateFreq *int32 `json:"revalidateFreq,omitempty"`
+}
+
+// CacheSpec defines the page caching done by the web pods.
+type CacheSpec struct {
+	// FastCGI enables a per-pod nginx fastcgi_cache for anonymous traffic.
+	// Requests other than GET and HEAD, requests with a query string, the
+	// admin, login, REST API and feed paths, as well as the logged-in users,
+	// commenters and carts are never cached.
+	// +optional
+	FastCGI *FastCGICacheSpec `json:"fastcgi,omitempty"`
+	// ObjectCache manages the object-cache.php drop-in, and optionally the
+	// advanced-cache.php one, for the selected backend. The drop-ins are
+	// mounted into the WP_CONTENT_DIR and load the ones shipped by the
+	// backend plugin, which needs to be part of the site code.
+	// +optional
+	ObjectCache *ObjectCacheSpec `json:"objectCache,omitempty"`
+}
+
+// ObjectCacheSpec defines the persistent object cache of the site.
+type ObjectCacheSpec struct {
+	// Backend is the object cache backend. The redis backend uses the Redis
+	// Object Cache plugin (redis-cache) and the memcached one the Memcached
+	// Object Cache plugin (memcached).
+	// +kubebuilder:validation:Enum=redis;memcached
+	Backend string `json:"backend"`
+	// Host is the address (host[:port]) of the cache server.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// PageCache enables the Batcache advanced-cache.php drop-in, which stores
+	// the full pages into the object cache. The batcache plugin needs to be
+	// part of the site code.
+	// +optional
+	PageCache bool `json:"pageCache,omitempty"`
+}
+
+// FastCGICacheSpec defines the nginx fastcgi_cache settings.
+type FastCGICacheSpec struct {
+	// MaxSize is the maximum size of the cache of each pod. Defaults to 256Mi.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// TTL is the amount of time the responses are cached for. Defaults to 1m.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// BypassCookies are additional cookie name patterns which disable the caching.
+	// +optional
+	BypassCookies []string `json:"bypassCookies,omitempty"`
+}
+
+// LoggingSpec defines how the site logs are collected.
+type LoggingSpec struct {
+	// Format is the format of the nginx access logs. The json format includes
+	// the request id set by the ingress controller. Defaults to combined.
+	// +kubebuilder:validation:Enum=json;combined
+	// +optional
+	Format string `json:"format,omitempty"`
+	// Sidecar injects a log shipping sidecar into the web pods, which tails
+	// the nginx, php-fpm and WordPress logs written under /var/log and ships
+	// them to the configured endpoint.
+	// +optional
+	Sidecar *LogShippingSidecarSpec `json:"sidecar,omitempty"`
+}
+
+// LogShippingSidecarSpec defines the log shipping sidecar.
+type LogShippingSidecarSpec struct {
+	// Type is the log shipper used by the sidecar. Defaults to fluent-bit.
+	// +kubebuilder:validation:Enum=fluent-bit;vector
+	// +optional
+	Type string `json:"type,omitempty"`
+	// Image overrides the log shipper image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Endpoint is the HTTP endpoint the logs are shipped to, as newline
+	// delimited JSON records.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+	// Headers are sent along with the logs. The values can reference
+	// environment variables set through envFrom (eg. ${LOGS_TOKEN}).
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// EnvFrom defines the sources of the environment variables of the sidecar.
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// Resources are the compute resources of the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// MediaGCSpec defines the periodic orphaned media files collection.
+type MediaGCSpec struct {
+	// Schedule of the media garbage collection job, in cron format. Defaults
+	// to "0 3 * * 0" (weekly).
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// DryRun only reports the orphaned media files in the job logs, without
+	// removing them.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// ConcurrencyPolicy specifies how to treat the concurrent runs of the
+	// media garbage collection job. Defaults to Forbid.
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	// +optional
+	ConcurrencyPolicy batchv1beta1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// StartingDeadlineSeconds is the deadline for starting the job, if it
+	// misses its scheduled time. The missed jobs are counted as failed.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// Suspend stops scheduling new jobs, without affecting the running ones.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// SuccessfulJobsHistoryLimit is the number of successful jobs to keep.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+	// FailedJobsHistoryLimit is the number of failed jobs to keep. Defaults
+	// to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailedJobsHistoryLimit *int32 `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// DBMaintenanceSpec defines the periodic database maintenance.
+type DBMaintenanceSpec struct {
+	// Schedule of the database maintenance job, in cron format. Defaults to
+	// "0 4 * * 0" (weekly).
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// DeleteAllTransients deletes all the transients, not only the expired
+	// ones. Use it for sites whose plugins leave transients without
+	// expiration behind.
+	// +optional
+	DeleteAllTransients bool `json:"deleteAllTransients,omitempty"`
+	// ConcurrencyPolicy specifies how to treat the concurrent runs of the
+	// database maintenance job. Defaults to Forbid.
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	// +optional
+	ConcurrencyPolicy batchv1beta1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// StartingDeadlineSeconds is the deadline for starting the job, if it
+	// misses its scheduled time. The missed jobs are counted as failed.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// Suspend stops scheduling new jobs, without affecting the running ones.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// SuccessfulJobsHistoryLimit is the number of successful jobs to keep.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+	// FailedJobsHistoryLimit is the number of failed jobs to keep. Defaults
+	// to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailedJobsHistoryLimit *int32 `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// MetricsSpec defines the collection of the runtime metrics.
+type MetricsSpec struct {
+	// Enabled injects the php-fpm and nginx exporter sidecars into the web
+	// pods and exposes their metrics through the site Service.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// ServiceMonitor creates a Prometheus Operator ServiceMonitor scraping the
+	// site metrics.
+	// +optional
+	ServiceMonitor *ServiceMonitorSpec `json:"serviceMonitor,omitempty"`
+	// Dashboard creates a ConfigMap holding a Grafana dashboard for the site,
+	// to be loaded by the Grafana dashboards sidecar.
+	// +optional
+	Dashboard *GrafanaDashboardSpec `json:"dashboard,omitempty"`
+	// Alerts creates a Prometheus Operator PrometheusRule holding the
+	// default alerts of the site.
+	// +optional
+	Alerts *AlertsSpec `json:"alerts,omitempty"`
+}
+
+// ServiceMonitorSpec defines the ServiceMonitor scraping the site metrics.
+type ServiceMonitorSpec struct {
+	// Interval is the interval at which the metrics are scraped. Defaults to 30s.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Labels are added to the ServiceMonitor (eg. for being selected by a
+	// Prometheus instance).
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GrafanaDashboardSpec defines the ConfigMap holding the Grafana dashboard of the site.
+type GrafanaDashboardSpec struct {
+	// Labels are added to the dashboard ConfigMap, so it gets picked up by
+	// the Grafana sidecar. Defaults to grafana_dashboard: "1".
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the dashboard ConfigMap (eg. for selecting
+	// the Grafana folder).
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AlertsSpec defines the PrometheusRule holding the alerts of the site.
+type AlertsSpec struct {
+	// Labels are added to the PrometheusRule (eg. for being selected by a
+	// Prometheus instance).
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// For is the duration for which a condition must hold before the alert
+	// fires. Defaults to 5m.
+	// +optional
+	For *metav1.Duration `json:"for,omitempty"`
+	// ErrorRatePercent is the percentage of 5xx responses above which the
+	// high error rate alert fires. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ErrorRatePercent *int32 `json:"errorRatePercent,omitempty"`
+	// ListenQueueLength is the php-fpm listen queue length above which the
+	// saturation alert fires. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ListenQueueLength *int32 `json:"listenQueueLength,omitempty"`
+	// CertificateExpiryDays is the number of days before the TLS certificate
+	// expiry when the certificate expiring alert fires. Defaults to 14.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CertificateExpiryDays *int32 `json:"certificateExpiryDays,omitempty"`
+}
+
+// SiteHealthSpec defines how the WordPress Site Health tests are run.
+type SiteHealthSpec struct {
+	// ApplicationPasswordSecretRef references a Secret holding the username
+	// and the application password (the username and password keys) of a
+	// user allowed to view the Site Health checks.
+	ApplicationPasswordSecretRef corev1.LocalObjectReference `json:"applicationPasswordSecretRef"`
+	// Interval is the amount of time after which the tests are run again.
+	// Defaults to 1h.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Tests are the names of the Site Health REST API tests to run. Defaults
+	// to background-updates, loopback-requests, https-status,
+	// dotorg-communication and authorization-header.
+	// +optional
+	Tests []string `json:"tests,omitempty"`
+}
+
+// ImagePolicySpec defines how the site image is kept up to date.
+type ImagePolicySpec struct {
+	// PinDigest enables resolving the image tag to a digest using the image
+	// pull secrets, so all the pods run the same image even if the tag moves.
+	// The tag is resolved again periodically and the site is rolled out when
+	// it points to a new digest.
+	// +optional
+	PinDigest bool `json:"pinDigest,omitempty"`
+	// CheckInterval is the amount of time after which the image tag is
+	// resolved again. Defaults to 1h.
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// DatabaseSpec defines how the site gets its database credentials.
+type DatabaseSpec struct {
+	// ExternalSecretRef specifies where to fetch the database credentials
+	// from, using External Secrets Operator. The operator creates an
+	// ExternalSecret which materializes the credentials into the <name>-db
+	// Secret, waits for it before rolling out the site and restarts the pods
+	// when the credentials get rotated. The external secret should define the
+	// DB_HOST, DB_USER, DB_PASSWORD and DB_NAME keys.
+	// +optional
+	ExternalSecretRef *ExternalSecretRef `json:"externalSecretRef,omitempty"`
+	// Pooling configures a ProxySQL sidecar pooling the database connections
+	// of the web pods, so sites with many replicas don't exhaust the MySQL
+	// max_connections.
+	// +optional
+	Pooling *DatabasePoolingSpec `json:"pooling,omitempty"`
+	// ReadReplicas configures the database replicas serving the read
+	// queries. A db-config.php file is rendered into the WP_CONTENT_DIR, for
+	// the HyperDB or LudicrousDB db.php drop-in, which needs to be part of the
+	// site code. The replicas are accessed using the site database credentials.
+	// +optional
+	ReadReplicas *DatabaseReadReplicasSpec `json:"readReplicas,omitempty"`
+}
+
+// DatabaseReadReplicasSpec defines the database replicas serving the read queries.
+type DatabaseReadReplicasSpec struct {
+	// Hosts are the addresses (host[:port]) of the database replicas.
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+	// ServiceRef references a Service in the site namespace, balancing the
+	// database replicas (eg. the one created by mysql-operator for the
+	// replicas of a cluster).
+	// +optional
+	ServiceRef *DatabaseServiceRef `json:"serviceRef,omitempty"`
+}
+
+// DatabaseServiceRef is a reference to a Service exposing a database.
+type DatabaseServiceRef struct {
+	// Name of the Service.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Port of the Service. Defaults to 3306.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// DatabasePoolingSpec defines the database connection pooling sidecar.
+type DatabasePoolingSpec struct {
+	// Enabled injects the ProxySQL sidecar into the web pods, which connect to
+	// the database through it.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Image is the ProxySQL image. Defaults to the operator configured one.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// MaxConnections is the maximum number of connections each web pod opens
+	// to the database. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+	// MaxClientConnections is the maximum number of php-fpm connections
+	// accepted by the sidecar. Defaults to 1024.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClientConnections *int32 `json:"maxClientConnections,omitempty"`
+	// Resources are the compute resources of the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// DeveloperAccessSpec defines the ways developers can access the site code.
+type DeveloperAccessSpec struct {
+	// SFTP injects an SFTP sidecar sharing the code volume into the web pods
+	// and exposes it through a dedicated Service.
+	// +optional
+	SFTP *SFTPSpec `json:"sftp,omitempty"`
+	// WebDAV injects a WebDAV sidecar exposing the media volume into the web
+	// pods and routes its domain to it.
+	// +optional
+	WebDAV *WebDAVSpec `json:"webdav,omitempty"`
+}
+
+// SFTPSpec defines the SFTP sidecar used for editing the site code.
+type SFTPSpec struct {
+	// AuthorizedKeysSecretRef references the Secret holding the public SSH
+	// keys allowed to log in, under the authorized_keys key.
+	AuthorizedKeysSecretRef corev1.LocalObjectReference `json:"authorizedKeysSecretRef"`
+	// HostKeysSecretRef references the Secret holding the SSH host keys, under
+	// the ssh_host_ed25519_key and ssh_host_rsa_key keys. If not set, the host
+	// keys get regenerated each time a pod starts.
+	// +optional
+	HostKeysSecretRef *corev1.LocalObjectReference `json:"hostKeysSecretRef,omitempty"`
+	// Image is the SFTP server image. Defaults to the operator configured one.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// ServiceType is the type of the SFTP Service. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Resources are the compute resources of the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// WebDAVSpec defines the WebDAV sidecar used for managing the media files.
+type WebDAVSpec struct {
+	// CredentialsSecretRef references the Secret holding the credentials
+	// required for accessing the WebDAV server, under the username and
+	// password keys.
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+	// Domain is the domain routed to the WebDAV server.
+	// +kubebuilder:validation:MinLength=1
+	Domain string `json:"domain"`
+	// TLSSecretRef is the secret holding the certificate for the WebDAV domain.
+	// +optional
+	TLSSecretRef string `json:"tlsSecretRef,omitempty"`
+	// Image is the WebDAV server image. Defaults to the operator configured one.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Resources are the compute resources of the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ExternalSecretRef is a reference to a secret stored in an external secret
+// store (eg. a Vault path).
+type ExternalSecretRef struct {
+	// StoreName is the name of the secret store to fetch the secret from.
+	// +kubebuilder:validation:MinLength=1
+	StoreName string `json:"storeName"`
+	// StoreKind is the kind of the secret store. Defaults to SecretStore.
+	// +kubebuilder:validation:Enum=SecretStore;ClusterSecretStore
+	// +optional
+	StoreKind string `json:"storeKind,omitempty"`
+	// Key is the path of the secret within the store (eg. the Vault path).
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// RefreshInterval is the amount of time after which the secret is
+	// fetched again from the store. Defaults to 1h.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// RoutingSpec defines how the requests are routed to the web pods.
+type RoutingSpec struct {
+	// Internal marks the site as reachable only through the web Service, from
+	// inside the cluster or the service mesh. No Ingress is created and the
+	// routes are only used for building the site URLs. Sites without routes
+	// are always internal.
+	// +optional
+	Internal bool `json:"internal,omitempty"`
+	// StickySessions pins the clients to the same web pod, using the client
+	// IP on the Service and an affinity cookie on the Ingress. This is needed
+	// by plugins that keep per-node state until a shared cache is configured.
+	// +optional
+	StickySessions *StickySessionsSpec `json:"stickySessions,omitempty"`
+}
+
+// ServiceSpec defines how the web Service is exposed.
+type ServiceSpec struct {
+	// Type is the type of the web Service. Use LoadBalancer or NodePort to
+	// expose the site directly through an L4 load balancer, without any
+	// Ingress controller. Note that the metrics ports are exposed as well.
+	// Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Annotations are added to the web Service, e.g. to configure the cloud
+	// provider load balancer.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LoadBalancerSourceRanges restricts the client IP ranges allowed
+	// through the load balancer. Only used by the LoadBalancer type.
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// ExternalTrafficPolicy is the external traffic policy of the NodePort
+	// and LoadBalancer types. Use Local to preserve the client IP.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+	// IPFamilyPolicy is the IP family policy of the web Service. Use
+	// PreferDualStack or RequireDualStack to serve IPv6 clients in dual-stack
+	// clusters. Defaults to the cluster one, usually SingleStack.
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
+	// IPFamilies are the IP families assigned to the web Service, in order.
+	// Defaults to the cluster ones, according to the IP family policy.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+	// TopologyAwareRouting enables the topology aware hints on the web
+	// Service, so the traffic is kept in the originating zone when possible,
+	// cutting the cross-zone data transfer in multi-zone clusters.
+	// +optional
+	TopologyAwareRouting bool `json:"topologyAwareRouting,omitempty"`
+}
+
+// StickySessionsSpec defines the session affinity settings.
+type StickySessionsSpec struct {
+	// CookieName is the name of the ingress affinity cookie. Defaults to
+	// wp-route.
+	// +optional
+	CookieName string `json:"cookieName,omitempty"`
+	// TimeoutSeconds is the duration of the session affinity, used both for
+	// the Service client IP affinity and the cookie max age. Defaults to
+	// 10800 (3 hours).
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AdminUserSpec defines a WordPress user managed by the operator.
+type AdminUserSpec struct {
+	// Username is the login name of the user.
+	// +kubebuilder:validation:MinLength=1
+	Username string `json:"username"`
+	// Email is the email address of the user.
+	// +kubebuilder:validation:MinLength=1
+	Email string `json:"email"`
+	// Role is the WordPress role of the user. Defaults to administrator.
+	// +optional
+	Role string `json:"role,omitempty"`
+	// PasswordSecretRef references the Secret key holding the user password.
+	// The password is set only when the user gets created and on resets.
+	PasswordSecretRef corev1.SecretKeySelector `json:"passwordSecretRef"`
+	// PasswordResetToken is an arbitrary value which, when changed, forces
+	// the user password to be reset to the one in the referenced Secret and
+	// the user sessions to be destroyed.
+	// +optional
+	PasswordResetToken string `json:"passwordResetToken,omitempty"`
+}
+
 // WordpressBootstrapSpec requires defining at least.
 // `WORDPRESS_BOOSTRAP_USER` and `WORDPRESS_BOOTSTRAP_PASSWORD` env variables.
 // `WORDPRESS_BOOSTRAP_EMAIL` and `WORDPRESS_BOOTSTRAP_TITLE` are also used if provided.
@@ -314,10 +1266,212 @@ type WordpressStatus struct {
 	// Conditions represents the Wordpress resource conditions list.
 	// +optional
 	Conditions []WordpressCondition `json:"conditions,omitempty"`
+	// ObservedGeneration is the most recent generation of the spec
+	// successfully reconciled by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// Total number of non-terminated pods targeted by web deployment
 	// This is copied over from the deployment object
 	// +optional
 	Replicas int32 `json:"replicas,omitempty"`
+	// Database represents the observed state of the database credentials.
+	// +optional
+	Database *DatabaseStatus `json:"database,omitempty"`
+	// Autoscaling represents the observed state of the web deployment autoscaling.
+	// +optional
+	Autoscaling *AutoscalingStatus `json:"autoscaling,omitempty"`
+	// Image represents the observed state of the image digest pinning.
+	// +optional
+	Image *ImageStatus `json:"image,omitempty"`
+	// Content represents the observed state of the WXR content export and import.
+	// +optional
+	Content *ContentStatus `json:"content,omitempty"`
+	// AdminUsers are the last applied admin users.
+	// +optional
+	AdminUsers []AdminUserStatus `json:"adminUsers,omitempty"`
+	// Usage summarizes the resources used by the site, for metering.
+	// +optional
+	Usage *UsageStatus `json:"usage,omitempty"`
+	// SiteHealth summarizes the results of the WordPress Site Health tests.
+	// +optional
+	SiteHealth *SiteHealthStatus `json:"siteHealth,omitempty"`
+	// ProvisionedNamespace is the namespace provisioned for a site created in
+	// the blueprint namespace.
+	// +optional
+	ProvisionedNamespace string `json:"provisionedNamespace,omitempty"`
+	// LoadBalancer is the address of the load balancer exposing the site
+	// ingress, or the web Service when its type is LoadBalancer, to which the
+	// site domains should point.
+	// +optional
+	LoadBalancer *corev1.LoadBalancerStatus `json:"loadBalancer,omitempty"`
+	// Internal is true if the site is reachable only from inside the cluster,
+	// as no Ingress is created for it.
+	// +optional
+	Internal bool `json:"internal,omitempty"`
+	// Resources are the objects created by the operator for the site. They
+	// are tracked for deleting the ones no longer needed when the spec changes.
+	// +optional
+	Resources []ResourceReference `json:"resources,omitempty"`
+}
+
+// ResourceReference identifies an object created by the operator for the site.
+type ResourceReference struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Name of the object.
+	Name string `json:"name"`
+}
+
+// SiteHealthStatus summarizes the results of the WordPress Site Health tests.
+type SiteHealthStatus struct {
+	// LastCheckTime is the last time the tests were run.
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+	// Critical is the number of tests reporting critical issues.
+	// +optional
+	Critical int32 `json:"critical,omitempty"`
+	// Recommended is the number of tests reporting recommended improvements.
+	// +optional
+	Recommended int32 `json:"recommended,omitempty"`
+	// Issues are the tests which didn't pass.
+	// +optional
+	Issues []SiteHealthIssue `json:"issues,omitempty"`
+}
+
+// SiteHealthIssue is the result of a Site Health test which didn't pass.
+type SiteHealthIssue struct {
+	// Test is the name of the test.
+	Test string `json:"test"`
+	// Status is the test result, either critical or recommended.
+	Status string `json:"status"`
+	// Label is the summary of the issue.
+	// +optional
+	Label string `json:"label,omitempty"`
+}
+
+// ContentStatus defines the observed state of the WXR content export and import.
+type ContentStatus struct {
+	// Export is the last completed content export.
+	// +optional
+	Export *ContentTransferStatus `json:"export,omitempty"`
+	// Import is the last completed content import.
+	// +optional
+	Import *ContentTransferStatus `json:"import,omitempty"`
+}
+
+// ContentTransferStatus defines a completed content export or import.
+type ContentTransferStatus struct {
+	// Token identifies the requested transfer. It is a hash of the
+	// annotation value, as the URL may embed credentials.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// CompletionTime is the time the transfer job completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// AdminUserStatus defines the last applied state of an admin user.
+type AdminUserStatus struct {
+	// Username is the login name of the user.
+	Username string `json:"username"`
+	// Token is a hash of the applied user spec.
+	// +optional
+	Token string `json:"token,omitempty"`
+	// PasswordResetToken is the last applied password reset token.
+	// +optional
+	PasswordResetToken string `json:"passwordResetToken,omitempty"`
+	// CompletionTime is the time the user job completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// UsageStatus summarizes the resources used by the site.
+type UsageStatus struct {
+	// Replicas is the number of web pods.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Requests are the compute resources requested by all the web pods.
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// Volumes are the capacity and the usage of the site volume claims.
+	// +optional
+	Volumes []VolumeUsage `json:"volumes,omitempty"`
+	// VolumeStatsTime is the last time the volume usage was collected from
+	// the kubelet.
+	// +optional
+	VolumeStatsTime *metav1.Time `json:"volumeStatsTime,omitempty"`
+}
+
+// VolumeUsage defines the capacity and the usage of a site volume claim.
+type VolumeUsage struct {
+	// Name is the site volume name, code or media.
+	Name string `json:"name"`
+	// ClaimName is the name of the volume claim.
+	ClaimName string `json:"claimName"`
+	// Capacity is the provisioned capacity of the claim.
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+	// Used is the space used on the volume, as reported by the kubelet. It's
+	// collected only if the volume stats collection is enabled.
+	// +optional
+	Used *resource.Quantity `json:"used,omitempty"`
+}
+
+// ImageStatus defines the observed state of the image digest pinning.
+type ImageStatus struct {
+	// Image is the image reference which was resolved.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Digest is the digest the image was resolved to.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// LastCheckTime is the last time the image tag was resolved.
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+	// LastUpdateTime is the last time the image digest changed.
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// DatabaseStatus defines the observed state of the database credentials.
+type DatabaseStatus struct {
+	// CredentialsRotationTime is the last time the database credentials were rotated.
+	// +optional
+	CredentialsRotationTime *metav1.Time `json:"credentialsRotationTime,omitempty"`
+	// CredentialsRotationToken is the value of the
+	// wordpress.presslabs.org/rotate-db-credentials annotation for which the
+	// last rotation was performed.
+	// +optional
+	CredentialsRotationToken string `json:"credentialsRotationToken,omitempty"`
+	// OldCredentialsRetained is set while the password replaced by the last
+	// rotation is still accepted by the database, until the web pods roll out
+	// with the new one.
+	// +optional
+	OldCredentialsRetained bool `json:"oldCredentialsRetained,omitempty"`
+}
+
+// AutoscalingStatus defines the observed state of the web deployment autoscaling.
+type AutoscalingStatus struct {
+	// Recommendations are the resources recommended by the
+	// VerticalPodAutoscaler for the web pod containers.
+	// +optional
+	Recommendations []ContainerRecommendation `json:"recommendations,omitempty"`
+}
+
+// ContainerRecommendation is the resources recommendation for a container.
+type ContainerRecommendation struct {
+	// ContainerName is the name of the container.
+	ContainerName string `json:"containerName"`
+	// Target is the recommended amount of resources.
+	Target corev1.ResourceList `json:"target"`
+	// LowerBound is the minimum recommended amount of resources.
+	// +optional
+	LowerBound corev1.ResourceList `json:"lowerBound,omitempty"`
+	// UpperBound is the maximum recommended amount of resources.
+	// +optional
+	UpperBound corev1.ResourceList `json:"upperBound,omitempty"`
 }
 
 // +genclient
@@ -346,7 +1500,3 @@ type WordpressList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []Wordpress `json:"items"`
 }
-
-func init() {
-	SchemeBuilder.Register(&Wordpress{}, &WordpressList{})
-}
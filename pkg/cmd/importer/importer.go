@@ -0,0 +1,305 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package importer generates Wordpress resources matching existing, vanilla,
+// WordPress deployments, easing their migration onto the operator.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+// the database settings of the official WordPress image and their bitpoke runtime counterparts
+var envRenames = map[string]string{
+	"WORDPRESS_DB_HOST":      "DB_HOST",
+	"WORDPRESS_DB_USER":      "DB_USER",
+	"WORDPRESS_DB_PASSWORD":  "DB_PASSWORD",
+	"WORDPRESS_DB_NAME":      "DB_NAME",
+	"WORDPRESS_TABLE_PREFIX": "DB_TABLE_PREFIX",
+}
+
+// Site is an existing WordPress deployment along with the Wordpress resource
+// generated for it.
+type Site struct {
+	// Wordpress is the generated resource.
+	Wordpress *wordpressv1alpha1.Wordpress
+	// Deployment is the inspected deployment.
+	Deployment *appsv1.Deployment
+	// Services are the services selecting the deployment pods.
+	Services []corev1.Service
+	// Ingresses are the ingresses routing traffic to the services.
+	Ingresses []netv1.Ingress
+	// Warnings are the settings which couldn't be carried over.
+	Warnings []string
+}
+
+// Inspect generates a Wordpress resource matching the given deployment, its
+// services, ingresses and volume claims.
+func Inspect(ctx context.Context, c client.Client, namespace, name string) (*Site, error) {
+	deploy := &appsv1.Deployment{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, deploy); err != nil {
+		return nil, err
+	}
+
+	site := &Site{
+		Deployment: deploy,
+		Wordpress: &wordpressv1alpha1.Wordpress{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: wordpressv1alpha1.SchemeGroupVersion.String(),
+				Kind:       "Wordpress",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      deploy.Name,
+				Namespace: deploy.Namespace,
+			},
+		},
+	}
+
+	site.inspectDeployment()
+
+	if err := site.inspectServices(ctx, c); err != nil {
+		return nil, err
+	}
+
+	if err := site.inspectIngresses(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return site, nil
+}
+
+func (s *Site) warn(format string, args ...interface{}) {
+	s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (s *Site) inspectDeployment() {
+	spec := &s.Wordpress.Spec
+	podSpec := &s.Deployment.Spec.Template.Spec
+
+	spec.Replicas = s.Deployment.Spec.Replicas
+	spec.ImagePullSecrets = podSpec.ImagePullSecrets
+	spec.ServiceAccountName = podSpec.ServiceAccountName
+
+	container := wordpressContainer(podSpec.Containers)
+	if container == nil {
+		s.warn("deployment %s has no containers", s.Deployment.Name)
+
+		return
+	}
+
+	s.warn("image %s is not carried over, the site runs on the operator runtime image", container.Image)
+
+	spec.Resources = container.Resources
+	spec.EnvFrom = container.EnvFrom
+
+	if len(container.EnvFrom) > 0 {
+		s.warn("the envFrom sources are carried over as they are, the WORDPRESS_DB_* variables must be renamed to DB_*")
+	}
+
+	for _, env := range container.Env {
+		if renamed, ok := envRenames[env.Name]; ok {
+			env.Name = renamed
+		}
+
+		spec.Env = append(spec.Env, env)
+	}
+
+	s.inspectVolumes(podSpec, container)
+}
+
+// wordpressContainer returns the container named wordpress or the first one.
+func wordpressContainer(containers []corev1.Container) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == "wordpress" {
+			return &containers[i]
+		}
+	}
+
+	if len(containers) > 0 {
+		return &containers[0]
+	}
+
+	return nil
+}
+
+// inspectVolumes uses the first volume claim mounted in the container, which
+// is expected to hold the WordPress document root, as the site code volume.
+func (s *Site) inspectVolumes(podSpec *corev1.PodSpec, container *corev1.Container) {
+	claims := map[string]string{}
+
+	for _, v := range podSpec.Volumes {
+		if v.PersistentVolumeClaim != nil {
+			claims[v.Name] = v.PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	for _, m := range container.VolumeMounts {
+		claim, ok := claims[m.Name]
+		if !ok {
+			continue
+		}
+
+		if s.Wordpress.Spec.CodeVolumeSpec != nil {
+			s.warn("volume claim %s, mounted at %s, is not carried over", claim, m.MountPath)
+
+			continue
+		}
+
+		s.Wordpress.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			ContentSubPath: path.Join(m.SubPath, "wp-content"),
+			PersistentVolumeClaim: &wordpressv1alpha1.CodePersistentVolumeClaimSpec{
+				ClaimName: claim,
+			},
+		}
+	}
+
+	if s.Wordpress.Spec.CodeVolumeSpec == nil {
+		s.warn("deployment %s mounts no volume claims, the site code and media must be set up", s.Deployment.Name)
+	}
+}
+
+func (s *Site) inspectServices(ctx context.Context, c client.Client) error {
+	services := &corev1.ServiceList{}
+	if err := c.List(ctx, services, client.InNamespace(s.Deployment.Namespace)); err != nil {
+		return err
+	}
+
+	podLabels := labels.Set(s.Deployment.Spec.Template.Labels)
+
+	for i := range services.Items {
+		selector := services.Items[i].Spec.Selector
+		if len(selector) > 0 && labels.SelectorFromSet(selector).Matches(podLabels) {
+			s.Services = append(s.Services, services.Items[i])
+		}
+	}
+
+	return nil
+}
+
+func (s *Site) inspectIngresses(ctx context.Context, c client.Client) error {
+	ingresses := &netv1.IngressList{}
+	if err := c.List(ctx, ingresses, client.InNamespace(s.Deployment.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range ingresses.Items {
+		ingress := &ingresses.Items[i]
+
+		routes := s.ingressRoutes(ingress)
+		if len(routes) == 0 {
+			continue
+		}
+
+		s.Ingresses = append(s.Ingresses, *ingress)
+		s.Wordpress.Spec.Routes = append(s.Wordpress.Spec.Routes, routes...)
+
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" || string(s.Wordpress.Spec.TLSSecretRef) == tls.SecretName {
+				continue
+			}
+
+			if s.Wordpress.Spec.TLSSecretRef == "" {
+				s.Wordpress.Spec.TLSSecretRef = wordpressv1alpha1.SecretRef(tls.SecretName)
+
+				continue
+			}
+
+			s.warn("TLS secret %s of ingress %s is not carried over", tls.SecretName, ingress.Name)
+		}
+	}
+
+	if len(s.Wordpress.Spec.Routes) == 0 {
+		s.warn("no ingress routes traffic to deployment %s, the site routes must be set", s.Deployment.Name)
+	}
+
+	return nil
+}
+
+// ingressRoutes returns the routes of the ingress rules backed by the site services.
+func (s *Site) ingressRoutes(ingress *netv1.Ingress) []wordpressv1alpha1.RouteSpec {
+	var routes []wordpressv1alpha1.RouteSpec
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == "" || rule.HTTP == nil {
+			continue
+		}
+
+		for _, p := range rule.HTTP.Paths {
+			if p.Backend.Service == nil || !s.hasService(p.Backend.Service.Name) {
+				continue
+			}
+
+			routes = append(routes, wordpressv1alpha1.RouteSpec{Domain: rule.Host, Path: p.Path})
+		}
+	}
+
+	return routes
+}
+
+func (s *Site) hasService(name string) bool {
+	for i := range s.Services {
+		if s.Services[i].Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Adopt replaces the deployment, along with its services and ingresses, with
+// the generated Wordpress resource. The volume claims are kept and get mounted
+// by the site. The original objects are deleted only once the Wordpress
+// resource gets created, so a rejected site doesn't leave the deployment down.
+func (s *Site) Adopt(ctx context.Context, c client.Client) error {
+	// validate the site, including by the admission webhooks, before changing anything
+	if err := c.Create(ctx, s.Wordpress.DeepCopy(), client.DryRunAll); err != nil {
+		return err
+	}
+
+	if err := c.Create(ctx, s.Wordpress); err != nil {
+		return err
+	}
+
+	objs := []client.Object{s.Deployment}
+
+	for i := range s.Services {
+		objs = append(objs, &s.Services[i])
+	}
+
+	for i := range s.Ingresses {
+		objs = append(objs, &s.Ingresses[i])
+	}
+
+	for _, obj := range objs {
+		if err := client.IgnoreNotFound(c.Delete(ctx, obj)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bitpoke/wordpress-operator/pkg/apis"
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+var _ = Describe("Importer", func() {
+	var (
+		c       client.Client
+		deploy  *appsv1.Deployment
+		svc     *corev1.Service
+		ingress *netv1.Ingress
+	)
+
+	BeforeEach(func() {
+		Expect(apis.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		podLabels := map[string]string{"app": "blog"}
+		replicas := int32(2)
+
+		deploy = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "blog", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: metav1.SetAsLabelSelector(podLabels),
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:  "wordpress",
+								Image: "wordpress:5.8-apache",
+								Env: []corev1.EnvVar{
+									{Name: "WORDPRESS_DB_HOST", Value: "mysql"},
+									{Name: "WP_DEBUG", Value: "false"},
+								},
+								VolumeMounts: []corev1.VolumeMount{
+									{Name: "html", MountPath: "/var/www/html"},
+								},
+							},
+						},
+						Volumes: []corev1.Volume{
+							{
+								Name: "html",
+								VolumeSource: corev1.VolumeSource{
+									PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "blog-html"},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		svc = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "blog-http", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: podLabels},
+		}
+		ingress = &netv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "blog", Namespace: "default"},
+			Spec: netv1.IngressSpec{
+				TLS: []netv1.IngressTLS{{Hosts: []string{"blog.example.com"}, SecretName: "blog-tls"}},
+				Rules: []netv1.IngressRule{
+					{
+						Host: "blog.example.com",
+						IngressRuleValue: netv1.IngressRuleValue{
+							HTTP: &netv1.HTTPIngressRuleValue{
+								Paths: []netv1.HTTPIngressPath{
+									{
+										Path: "/",
+										Backend: netv1.IngressBackend{
+											Service: &netv1.IngressServiceBackend{Name: svc.Name},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deploy, svc, ingress).Build()
+	})
+
+	It("generates a Wordpress matching the deployment", func() {
+		site, err := Inspect(context.TODO(), c, "default", "blog")
+		Expect(err).ToNot(HaveOccurred())
+
+		spec := site.Wordpress.Spec
+		Expect(site.Wordpress.Name).To(Equal("blog"))
+		Expect(*spec.Replicas).To(Equal(int32(2)))
+		Expect(spec.Env).To(Equal([]corev1.EnvVar{
+			{Name: "DB_HOST", Value: "mysql"},
+			{Name: "WP_DEBUG", Value: "false"},
+		}))
+		Expect(spec.CodeVolumeSpec.PersistentVolumeClaim.ClaimName).To(Equal("blog-html"))
+		Expect(spec.CodeVolumeSpec.ContentSubPath).To(Equal("wp-content"))
+		Expect(spec.Routes).To(Equal([]wordpressv1alpha1.RouteSpec{{Domain: "blog.example.com", Path: "/"}}))
+		Expect(spec.TLSSecretRef).To(Equal(wordpressv1alpha1.SecretRef("blog-tls")))
+
+		Expect(site.Services).To(HaveLen(1))
+		Expect(site.Ingresses).To(HaveLen(1))
+	})
+
+	It("replaces the deployment when adopting it", func() {
+		site, err := Inspect(context.TODO(), c, "default", "blog")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(site.Adopt(context.TODO(), c)).To(Succeed())
+
+		key := types.NamespacedName{Name: "blog", Namespace: "default"}
+		Expect(c.Get(context.TODO(), key, &appsv1.Deployment{})).ToNot(Succeed())
+		Expect(c.Get(context.TODO(), key, &netv1.Ingress{})).ToNot(Succeed())
+		Expect(c.Get(context.TODO(), types.NamespacedName{Name: svc.Name, Namespace: "default"}, &corev1.Service{})).ToNot(Succeed())
+		Expect(c.Get(context.TODO(), key, &wordpressv1alpha1.Wordpress{})).To(Succeed())
+	})
+
+	It("keeps the deployment when the site can't be created", func() {
+		site, err := Inspect(context.TODO(), c, "default", "blog")
+		Expect(err).ToNot(HaveOccurred())
+
+		existing := &wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "blog", Namespace: "default"},
+		}
+		Expect(c.Create(context.TODO(), existing)).To(Succeed())
+
+		Expect(site.Adopt(context.TODO(), c)).ToNot(Succeed())
+
+		key := types.NamespacedName{Name: "blog", Namespace: "default"}
+		Expect(c.Get(context.TODO(), key, &appsv1.Deployment{})).To(Succeed())
+		Expect(c.Get(context.TODO(), key, &netv1.Ingress{})).To(Succeed())
+		Expect(c.Get(context.TODO(), types.NamespacedName{Name: svc.Name, Namespace: "default"}, &corev1.Service{})).To(Succeed())
+	})
+})
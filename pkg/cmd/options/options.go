@@ -19,7 +19,9 @@ package options
 import (
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -30,6 +32,12 @@ var (
 	// GitCloneImage is the image used by the init container that clones the code.
 	GitCloneImage = "docker.io/library/buildpack-deps:stretch-scm"
 
+	// OCIPullImage is the image used by the init container that pulls the code from OCI artifacts.
+	OCIPullImage = "ghcr.io/oras-project/oras:v0.12.0"
+
+	// ObjectStorageDownloadImage is the image used by the init container that downloads the code from object storage.
+	ObjectStorageDownloadImage = "docker.io/rclone/rclone:1.57.0"
+
 	// WordpressRuntimeImage is the base image used to run your code.
 	WordpressRuntimeImage = "docker.io/bitpoke/wordpress-runtime:5.8.2"
 
@@ -51,6 +59,133 @@ var (
 
 	// HealthProbeBindAddress is the TCP address that the controller should bind to for serving health probes.
 	HealthProbeBindAddress = ":8081"
+
+	// RevisionHistoryLimit is the default number of old ReplicaSets kept for the web deployments.
+	RevisionHistoryLimit int32 = 3
+
+	// MinReadySeconds is the default minimum number of seconds for which a web pod should be ready to be available.
+	MinReadySeconds int32
+
+	// ProgressDeadlineSeconds is the default maximum time in seconds for a web deployment rollout to make progress.
+	ProgressDeadlineSeconds int32 = 600
+
+	// DrainDelaySeconds is the time the web pods wait, before stopping, for being removed from the service endpoints.
+	DrainDelaySeconds int32 = 5
+
+	// TerminationGracePeriodSeconds is the default time the site pods are given for stopping gracefully.
+	TerminationGracePeriodSeconds int64 = 60
+
+	// JobTTLSecondsAfterFinished is the time after which the finished jobs created by the operator are deleted.
+	JobTTLSecondsAfterFinished int32 = 3600
+
+	// DebugShellTTLSeconds is the time after which the debug shell pods are stopped.
+	DebugShellTTLSeconds int64 = 3600
+
+	// JobBackoffLimit is the number of retries of the jobs created by the operator. The jobs are retried, as by
+	// default in Kubernetes, so they survive transient failures, like the database not being ready yet.
+	JobBackoffLimit int32 = 6
+
+	// RetryBaseDelay is the delay before retrying, for the first time, a failed site reconciliation.
+	// The delay doubles with each consecutive failure.
+	RetryBaseDelay = 5 * time.Millisecond
+
+	// RetryMaxDelay is the maximum delay before retrying a failed site reconciliation.
+	RetryMaxDelay = 1000 * time.Second
+
+	// S3BucketRegion is the AWS region in which the media S3 buckets are provisioned.
+	S3BucketRegion = "us-east-1"
+
+	// PHPConfigDir is the directory from which the runtime image loads additional php.ini files.
+	PHPConfigDir = "/usr/local/etc/php/conf.d"
+
+	// NginxConfigDir is the directory from which the runtime image nginx loads additional http context configuration files.
+	NginxConfigDir = "/etc/nginx/conf.d"
+
+	// FluentBitImage is the image used by the fluent-bit log shipping sidecars.
+	FluentBitImage = "docker.io/fluent/fluent-bit:1.8.9"
+
+	// VectorImage is the image used by the vector log shipping sidecars.
+	VectorImage = "docker.io/timberio/vector:0.17.3-alpine"
+
+	// NginxExporterImage is the image used by the nginx metrics exporter sidecars.
+	NginxExporterImage = "docker.io/nginx/nginx-prometheus-exporter:0.9.0"
+
+	// PHPFPMExporterImage is the image used by the php-fpm metrics exporter sidecars.
+	PHPFPMExporterImage = "docker.io/hipages/php-fpm_exporter:2.0.4"
+
+	// PHPFPMStatusURI is the php-fpm status page scraped by the php-fpm metrics exporter sidecars.
+	PHPFPMStatusURI = "tcp://127.0.0.1:9000/status"
+
+	// ProxySQLImage is the image used by the database connection pooling sidecars.
+	ProxySQLImage = "docker.io/proxysql/proxysql:2.3.2"
+
+	// SFTPImage is the image used by the SFTP sidecars.
+	SFTPImage = "docker.io/atmoz/sftp:debian"
+
+	// WebDAVImage is the image used by the WebDAV sidecars.
+	WebDAVImage = "docker.io/rclone/rclone:1.57.0"
+
+	// BlueprintNamespace is the namespace in which creating a Wordpress provisions a dedicated namespace
+	// for the site. Empty disables the namespace provisioning.
+	BlueprintNamespace = ""
+
+	// ProvisionedNamespacePrefix is the prefix of the namespaces provisioned for the sites created in the blueprint namespace.
+	ProvisionedNamespacePrefix = "wp-"
+
+	// WebhooksEnabled determines whether or not the admission webhooks are served.
+	WebhooksEnabled = false
+
+	// WebhookPort is the port on which the admission webhooks are served.
+	WebhookPort = 9443
+
+	// WebhookCertDir is the directory containing the serving certificate (tls.crt) and key (tls.key) of the admission webhooks.
+	WebhookCertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+	// MaxReplicas is the maximum number of web pods a site may request. Zero means no limit.
+	MaxReplicas int32
+
+	// MaxStorage is the maximum size of the volume claims a site may request. Empty means no limit.
+	MaxStorage = ""
+
+	// MaxCPU is the maximum CPU a site container may request or be limited to. Empty means no limit.
+	MaxCPU = ""
+
+	// MaxMemory is the maximum memory a site container may request or be limited to. Empty means no limit.
+	MaxMemory = ""
+
+	// CrossplaneProviderConfig is the name of the Crossplane ProviderConfig used for provisioning S3 buckets.
+	CrossplaneProviderConfig = "default"
+
+	// BlackboxExporterURL is the address (host:port) of the Prometheus blackbox exporter probing the site routes. Empty
+	// disables the generation of the Prometheus Operator Probe objects.
+	BlackboxExporterURL = ""
+
+	// BlackboxExporterModule is the blackbox exporter module used for probing the site routes.
+	BlackboxExporterModule = "http_2xx"
+
+	// VolumeStatsEnabled determines whether or not the usage of the site volumes is collected from the kubelets,
+	// through the nodes proxy.
+	VolumeStatsEnabled = false
+
+	// VolumeStatsInterval is the interval at which the usage of the site volumes is collected.
+	VolumeStatsInterval = 5 * time.Minute
+
+	// CacheManagedObjectsOnly restricts the cached Secrets, ConfigMaps, Deployments, Services, PVCs, Ingresses, Jobs,
+	// ServiceAccounts and Pods to the ones created by the operator. The objects referenced by the sites are then
+	// read directly from the API server. Only the metadata of the Secrets and ConfigMaps is kept in a separate cache,
+	// so the changes of the ones referenced through envFrom still roll the sites.
+	CacheManagedObjectsOnly = false
+
+	// ExtraResourceKinds are the kinds, as Kind.group, of the objects which may be created through the sites
+	// spec.extraResources. The objects are created with the operator permissions, so none are allowed by default.
+	ExtraResourceKinds []string
+
+	// Shards is the number of operator replicas between which the sites are partitioned.
+	Shards int32 = 1
+
+	// Shard is the index of the sites partition reconciled by this operator replica. Defaults to the ordinal
+	// of the StatefulSet pod running the operator.
+	Shard = shardOrdinal()
 )
 
 func namespace() string {
@@ -71,9 +206,32 @@ func namespace() string {
 	return corev1.NamespaceDefault
 }
 
+// shardOrdinal returns the ordinal of the StatefulSet pod from its hostname (eg. wordpress-operator-2).
+func shardOrdinal() int32 {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0
+	}
+
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0
+	}
+
+	ordinal, err := strconv.ParseInt(hostname[i+1:], 10, 32)
+	if err != nil || ordinal < 0 {
+		return 0
+	}
+
+	return int32(ordinal)
+}
+
 // AddToFlagSet set command line arguments.
 func AddToFlagSet(flag *pflag.FlagSet) {
 	flag.StringVar(&GitCloneImage, "git-clone-image", GitCloneImage, "The image used when cloning code from git.")
+	flag.StringVar(&OCIPullImage, "oci-pull-image", OCIPullImage, "The image used when pulling code from OCI artifacts.")
+	flag.StringVar(&ObjectStorageDownloadImage, "object-storage-download-image", ObjectStorageDownloadImage,
+		"The image used when downloading code from object storage.")
 	flag.StringVar(&WordpressRuntimeImage, "wordpress-runtime-image", WordpressRuntimeImage, "The base image used for Wordpress.")
 	flag.StringVar(&IngressClass, "ingress-class", IngressClass, "The default ingress class for WordPress sites.")
 	flag.BoolVar(&LeaderElection, "leader-election", LeaderElection, "Enables or disables controller leader election.")
@@ -82,4 +240,58 @@ func AddToFlagSet(flag *pflag.FlagSet) {
 	flag.StringVar(&MetricsBindAddress, "metrics-addr", MetricsBindAddress, "The TCP address that the controller should bind to for serving prometheus metrics."+
 		" It can be set to \"0\" to disable the metrics serving.")
 	flag.StringVar(&HealthProbeBindAddress, "healthz-addr", HealthProbeBindAddress, "The TCP address that the controller should bind to for serving health probes.")
+	flag.Int32Var(&RevisionHistoryLimit, "revision-history-limit", RevisionHistoryLimit,
+		"The default number of old ReplicaSets kept for the web deployments.")
+	flag.Int32Var(&MinReadySeconds, "min-ready-seconds", MinReadySeconds,
+		"The default minimum number of seconds for which a web pod should be ready to be considered available.")
+	flag.Int32Var(&ProgressDeadlineSeconds, "progress-deadline-seconds", ProgressDeadlineSeconds,
+		"The default maximum time in seconds for a web deployment rollout to make progress.")
+	flag.Int32Var(&DrainDelaySeconds, "drain-delay-seconds", DrainDelaySeconds,
+		"The time, in seconds, the web pods wait before stopping, for being removed from the service endpoints.")
+	flag.Int64Var(&TerminationGracePeriodSeconds, "termination-grace-period-seconds", TerminationGracePeriodSeconds,
+		"The default time, in seconds, the site pods are given for stopping gracefully.")
+	flag.Int32Var(&JobTTLSecondsAfterFinished, "job-ttl-seconds-after-finished", JobTTLSecondsAfterFinished,
+		"The time, in seconds, after which the finished jobs created by the operator are deleted.")
+	flag.Int32Var(&JobBackoffLimit, "job-backoff-limit", JobBackoffLimit, "The number of retries of the jobs created by the operator.")
+	flag.Int64Var(&DebugShellTTLSeconds, "debug-shell-ttl-seconds", DebugShellTTLSeconds,
+		"The time, in seconds, after which the debug shell pods are stopped.")
+	flag.DurationVar(&RetryBaseDelay, "retry-base-delay", RetryBaseDelay,
+		"The delay before retrying, for the first time, a failed site reconciliation. The delay doubles with each consecutive failure.")
+	flag.DurationVar(&RetryMaxDelay, "retry-max-delay", RetryMaxDelay, "The maximum delay before retrying a failed site reconciliation.")
+	flag.StringVar(&S3BucketRegion, "s3-bucket-region", S3BucketRegion, "The AWS region in which the media S3 buckets are provisioned.")
+	flag.StringVar(&PHPConfigDir, "php-config-dir", PHPConfigDir, "The directory from which the runtime image loads additional php.ini files.")
+	flag.StringVar(&NginxConfigDir, "nginx-config-dir", NginxConfigDir,
+		"The directory from which the runtime image nginx loads additional http context configuration files.")
+	flag.StringVar(&FluentBitImage, "fluent-bit-image", FluentBitImage, "The image used by the fluent-bit log shipping sidecars.")
+	flag.StringVar(&VectorImage, "vector-image", VectorImage, "The image used by the vector log shipping sidecars.")
+	flag.StringVar(&NginxExporterImage, "nginx-exporter-image", NginxExporterImage, "The image used by the nginx metrics exporter sidecars.")
+	flag.StringVar(&PHPFPMExporterImage, "php-fpm-exporter-image", PHPFPMExporterImage, "The image used by the php-fpm metrics exporter sidecars.")
+	flag.StringVar(&PHPFPMStatusURI, "php-fpm-status-uri", PHPFPMStatusURI, "The php-fpm status page scraped by the php-fpm metrics exporter sidecars.")
+	flag.StringVar(&ProxySQLImage, "proxysql-image", ProxySQLImage, "The image used by the database connection pooling sidecars.")
+	flag.StringVar(&SFTPImage, "sftp-image", SFTPImage, "The image used by the SFTP sidecars.")
+	flag.StringVar(&WebDAVImage, "webdav-image", WebDAVImage, "The image used by the WebDAV sidecars.")
+	flag.StringVar(&BlueprintNamespace, "blueprint-namespace", BlueprintNamespace,
+		"The namespace in which creating a Wordpress provisions a dedicated namespace for the site. Empty disables the namespace provisioning.")
+	flag.StringVar(&ProvisionedNamespacePrefix, "provisioned-namespace-prefix", ProvisionedNamespacePrefix,
+		"The prefix of the namespaces provisioned for the sites created in the blueprint namespace.")
+	flag.BoolVar(&WebhooksEnabled, "enable-webhooks", WebhooksEnabled, "Enables or disables the admission webhooks.")
+	flag.IntVar(&WebhookPort, "webhook-port", WebhookPort, "The port on which the admission webhooks are served.")
+	flag.StringVar(&WebhookCertDir, "webhook-cert-dir", WebhookCertDir, "The directory containing the serving certificate and key of the admission webhooks.")
+	flag.Int32Var(&MaxReplicas, "max-replicas", MaxReplicas, "The maximum number of web pods a site may request. Zero means no limit.")
+	flag.StringVar(&MaxStorage, "max-storage", MaxStorage, "The maximum size of the volume claims a site may request. Empty means no limit.")
+	flag.StringVar(&MaxCPU, "max-cpu", MaxCPU, "The maximum CPU a site container may request or be limited to. Empty means no limit.")
+	flag.StringVar(&MaxMemory, "max-memory", MaxMemory, "The maximum memory a site container may request or be limited to. Empty means no limit.")
+	flag.StringVar(&CrossplaneProviderConfig, "crossplane-provider-config", CrossplaneProviderConfig, "The Crossplane ProviderConfig used for provisioning S3 buckets.")
+	flag.StringVar(&BlackboxExporterURL, "blackbox-exporter-url", BlackboxExporterURL,
+		"The address (host:port) of the Prometheus blackbox exporter probing the site routes. Empty disables the Probe objects generation.")
+	flag.StringVar(&BlackboxExporterModule, "blackbox-exporter-module", BlackboxExporterModule, "The blackbox exporter module used for probing the site routes.")
+	flag.BoolVar(&VolumeStatsEnabled, "collect-volume-stats", VolumeStatsEnabled,
+		"Enables or disables collecting the usage of the site volumes from the kubelets. Requires access to the nodes/proxy resource.")
+	flag.DurationVar(&VolumeStatsInterval, "volume-stats-interval", VolumeStatsInterval, "The interval at which the usage of the site volumes is collected.")
+	flag.BoolVar(&CacheManagedObjectsOnly, "cache-managed-objects-only", CacheManagedObjectsOnly,
+		"Restricts the cache to the objects created by the operator, to reduce the memory used in large clusters.")
+	flag.StringSliceVar(&ExtraResourceKinds, "extra-resource-kinds", ExtraResourceKinds,
+		"The kinds, as Kind.group (eg. ConfigMap or Distribution.cdn.example.com), of the objects which may be created through the sites extraResources.")
+	flag.Int32Var(&Shards, "shards", Shards, "The number of operator replicas between which the sites are partitioned.")
+	flag.Int32Var(&Shard, "shard", Shard, "The partition of sites reconciled by this replica. Defaults to the StatefulSet pod ordinal.")
 }
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// reconcileAdminUsers applies the admin users whose spec changed since they
+// were last applied. Each user is applied by a wp-cli job and, once the job
+// completes, the user is recorded into the status, so the job doesn't run
+// again until the user spec changes.
+func (r *ReconcileWordpress) reconcileAdminUsers(ctx context.Context, wp *wordpress.Wordpress) error {
+	pruneAdminUsersStatus(wp)
+
+	if len(wp.Spec.AdminUsers) == 0 {
+		return nil
+	}
+
+	var running, failed []string
+
+	for _, user := range wp.PendingAdminUsers() {
+		jobSyncer := sync.NewAdminUserJobSyncer(wp, user, r.Client)
+		if err := syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+			return err
+		}
+
+		job := jobSyncer.Object().(*batchv1.Job)
+
+		switch {
+		case job.Status.Succeeded > 0:
+			setAdminUserStatus(wp, user)
+			r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.AdminUsersReconciledReason,
+				fmt.Sprintf("user %s has been applied", user.Username))
+		case job.Status.Failed > 0:
+			failed = append(failed, job.Name)
+		default:
+			running = append(running, job.Name)
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		wp.SetCondition(wordpressv1alpha1.AdminUsersReadyCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.AdminUserReconcileFailedReason, fmt.Sprintf("jobs %s have failed", strings.Join(failed, ", ")))
+	case len(running) > 0:
+		wp.SetCondition(wordpressv1alpha1.AdminUsersReadyCondition, corev1.ConditionUnknown,
+			wordpressv1alpha1.AdminUsersReconcilingReason, fmt.Sprintf("waiting for jobs %s to complete", strings.Join(running, ", ")))
+	default:
+		wp.SetCondition(wordpressv1alpha1.AdminUsersReadyCondition, corev1.ConditionTrue,
+			wordpressv1alpha1.AdminUsersReconciledReason, "all the admin users have been applied")
+	}
+
+	return nil
+}
+
+func setAdminUserStatus(wp *wordpress.Wordpress, user *wordpressv1alpha1.AdminUserSpec) {
+	now := metav1.Now()
+	applied := wordpressv1alpha1.AdminUserStatus{
+		Username:           user.Username,
+		Token:              wordpress.AdminUserToken(user),
+		PasswordResetToken: user.PasswordResetToken,
+		CompletionTime:     &now,
+	}
+
+	if status := wp.AdminUserStatus(user.Username); status != nil {
+		*status = applied
+
+		return
+	}
+
+	wp.Status.AdminUsers = append(wp.Status.AdminUsers, applied)
+}
+
+// pruneAdminUsersStatus drops the users removed from the spec, so they get
+// applied again if added back.
+func pruneAdminUsersStatus(wp *wordpress.Wordpress) {
+	var users []wordpressv1alpha1.AdminUserStatus
+
+	for _, status := range wp.Status.AdminUsers {
+		for i := range wp.Spec.AdminUsers {
+			if wp.Spec.AdminUsers[i].Username == status.Username {
+				users = append(users, status)
+
+				break
+			}
+		}
+	}
+
+	wp.Status.AdminUsers = users
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// isAutoscaled returns true if the site deployment is scaled by a
+// HorizontalPodAutoscaler, in which case spec.replicas must not be enforced,
+// otherwise the operator and the autoscaler would fight each other.
+func (r *ReconcileWordpress) isAutoscaled(ctx context.Context, wp *wordpress.Wordpress) (bool, error) {
+	hpas := &autoscalingv1.HorizontalPodAutoscalerList{}
+	if err := r.List(ctx, hpas, client.InNamespace(wp.Namespace)); err != nil {
+		return false, err
+	}
+
+	deployName := wp.ComponentName(wordpress.WordpressDeployment)
+	autoscaler := ""
+
+	for i := range hpas.Items {
+		ref := hpas.Items[i].Spec.ScaleTargetRef
+		if ref.Kind == "Deployment" && ref.Name == deployName {
+			autoscaler = hpas.Items[i].Name
+
+			break
+		}
+	}
+
+	if autoscaler != "" && wp.Spec.Replicas != nil {
+		wp.SetCondition(wordpressv1alpha1.ReplicasConflictCondition, corev1.ConditionTrue,
+			wordpressv1alpha1.HorizontalPodAutoscalerReason,
+			fmt.Sprintf("spec.replicas is ignored, as the deployment is scaled by HorizontalPodAutoscaler %s", autoscaler))
+	} else if wp.GetCondition(wordpressv1alpha1.ReplicasConflictCondition) != nil {
+		wp.SetCondition(wordpressv1alpha1.ReplicasConflictCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.NoReplicasConflictReason, "spec.replicas doesn't conflict with any autoscaler")
+	}
+
+	return autoscaler != "", nil
+}
+
+// updateVPARecommendations copies the resources recommended by the
+// VerticalPodAutoscaler into the site status.
+func updateVPARecommendations(wp *wordpress.Wordpress, vpa *unstructured.Unstructured) error {
+	recommendation, found, err := unstructured.NestedMap(vpa.Object, "status", "recommendation")
+	if err != nil || !found {
+		return err
+	}
+
+	status := struct {
+		ContainerRecommendations []wordpressv1alpha1.ContainerRecommendation `json:"containerRecommendations"`
+	}{}
+
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(recommendation, &status); err != nil {
+		return err
+	}
+
+	if wp.Status.Autoscaling == nil {
+		wp.Status.Autoscaling = &wordpressv1alpha1.AutoscalingStatus{}
+	}
+
+	wp.Status.Autoscaling.Recommendations = status.ContainerRecommendations
+
+	return nil
+}
+
+// hpaToWordpress maps the HorizontalPodAutoscalers targeting a deployment to
+// the site owning it. The site deployment is named after the site.
+func hpaToWordpress(obj client.Object) []reconcile.Request {
+	hpa, ok := obj.(*autoscalingv1.HorizontalPodAutoscaler)
+	if !ok || hpa.Spec.ScaleTargetRef.Kind != "Deployment" {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Name:      hpa.Spec.ScaleTargetRef.Name,
+				Namespace: hpa.Namespace,
+			},
+		},
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+)
+
+// CacheSelectors restricts the cache of the site resources to the ones
+// created by the operator, so the Secrets or Pods of the whole cluster don't
+// get cached.
+func CacheSelectors() cache.SelectorsByObject {
+	managed := sync.ManagedSelector()
+
+	return cache.SelectorsByObject{
+		&appsv1.Deployment{}:            {Label: managed},
+		&batchv1.Job{}:                  {Label: managed},
+		&corev1.ConfigMap{}:             {Label: managed},
+		&corev1.PersistentVolumeClaim{}: {Label: managed},
+		&corev1.Pod{}:                   {Label: managed},
+		&corev1.Secret{}:                {Label: managed},
+		&corev1.Service{}:               {Label: managed},
+		&corev1.ServiceAccount{}:        {Label: managed},
+		&netv1.Ingress{}:                {Label: managed},
+	}
+}
+
+// referencesReader returns the reader of the objects referenced by the
+// sites, which are not created by the operator, so they are missing from the
+// cache when it's restricted.
+func (r *ReconcileWordpress) referencesReader() client.Reader {
+	if options.CacheManagedObjectsOnly {
+		return r.apiReader
+	}
+
+	return r.Client
+}
+
+// envFromSources returns, by kind, the sources of the events of the Secrets
+// and ConfigMaps referenced through envFrom. They are missing from the cache
+// when it's restricted, so they are watched through a dedicated cache, which
+// holds only their metadata.
+func envFromSources(mgr manager.Manager) (map[string]source.Source, error) {
+	if !options.CacheManagedObjectsOnly {
+		return map[string]source.Source{
+			"Secret":    &source.Kind{Type: &corev1.Secret{}},
+			"ConfigMap": &source.Kind{Type: &corev1.ConfigMap{}},
+		}, nil
+	}
+
+	metadataCache, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = mgr.Add(metadataCache); err != nil {
+		return nil, err
+	}
+
+	sources := map[string]source.Source{}
+
+	for _, kind := range []string{"Secret", "ConfigMap"} {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+		sources[kind] = source.NewKindWithCache(obj, metadataCache)
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// transferContent drives the WXR content export and import requested through
+// the wordpress.presslabs.org/export-content and import-content annotations.
+// Each transfer runs as a wp-cli job and, once the job completes, the
+// transfer is recorded into the status, so it doesn't run again until the
+// annotation changes.
+func (r *ReconcileWordpress) transferContent(ctx context.Context, wp *wordpress.Wordpress) error {
+	if url := wp.PendingContentExport(); url != "" {
+		done, err := r.runContentTransferJob(ctx, wp, sync.NewContentExportJobSyncer(wp, r.Client), wordpressv1alpha1.ContentExportCondition)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			contentStatus(wp).Export = completedContentTransfer(url)
+		}
+	}
+
+	if url := wp.PendingContentImport(); url != "" {
+		done, err := r.runContentTransferJob(ctx, wp, sync.NewContentImportJobSyncer(wp, r.Client), wordpressv1alpha1.ContentImportCondition)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			contentStatus(wp).Import = completedContentTransfer(url)
+		}
+	}
+
+	return nil
+}
+
+// runContentTransferJob syncs the transfer job, reflects its state into the
+// given condition and returns true once the job completes successfully.
+func (r *ReconcileWordpress) runContentTransferJob(ctx context.Context, wp *wordpress.Wordpress, jobSyncer syncer.Interface,
+	condition wordpressv1alpha1.WordpressConditionType) (bool, error) {
+	if err := syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+		return false, err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case job.Status.Succeeded > 0:
+		wp.SetCondition(condition, corev1.ConditionTrue,
+			wordpressv1alpha1.ContentTransferSucceededReason, fmt.Sprintf("job %s has completed", job.Name))
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.ContentTransferSucceededReason,
+			fmt.Sprintf("job %s has completed", job.Name))
+
+		return true, nil
+	case job.Status.Failed > 0:
+		wp.SetCondition(condition, corev1.ConditionFalse,
+			wordpressv1alpha1.ContentTransferFailedReason, fmt.Sprintf("job %s has failed", job.Name))
+	default:
+		wp.SetCondition(condition, corev1.ConditionUnknown,
+			wordpressv1alpha1.ContentTransferRunningReason, fmt.Sprintf("waiting for job %s to complete", job.Name))
+	}
+
+	return false, nil
+}
+
+func contentStatus(wp *wordpress.Wordpress) *wordpressv1alpha1.ContentStatus {
+	if wp.Status.Content == nil {
+		wp.Status.Content = &wordpressv1alpha1.ContentStatus{}
+	}
+
+	return wp.Status.Content
+}
+
+func completedContentTransfer(url string) *wordpressv1alpha1.ContentTransferStatus {
+	now := metav1.Now()
+
+	return &wordpressv1alpha1.ContentTransferStatus{
+		Token:          wordpress.ContentTransferToken(url),
+		CompletionTime: &now,
+	}
+}
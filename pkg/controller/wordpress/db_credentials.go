@@ -0,0 +1,139 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/presslabs/controller-util/syncer"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// rotateDBCredentials drives the database credentials rotation requested
+// through the wordpress.presslabs.org/rotate-db-credentials annotation. The
+// new password, generated into a dedicated secret, is set for the database
+// user by a wp-cli job. Once the job succeeds, the password gets promoted in
+// the site secret, which rolls the site pods, and the rotation is recorded
+// into the status. The old password, if retained by the database, is
+// discarded once all the pods use the new one.
+func (r *ReconcileWordpress) rotateDBCredentials(ctx context.Context, wp *wordpress.Wordpress,
+	secret, next *corev1.Secret, deploy *appsv1.Deployment) error {
+	token := wp.PendingDBCredentialsRotation()
+	if token == "" {
+		return r.discardOldDBCredentials(ctx, wp, secret, deploy)
+	}
+
+	if wp.HasExternalDatabaseSecret() {
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.DBCredentialsRotationFailedReason,
+			"the database credentials are managed by an external secret store and can't be rotated")
+
+		return nil
+	}
+
+	jobSyncer := sync.NewDBCredentialsRotationJobSyncer(wp, r.Client)
+	if err := syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case job.Status.Succeeded > 0:
+		if !bytes.Equal(secret.Data[wordpress.DBPasswordKey], next.Data[wordpress.NextDBPasswordKey]) {
+			secret.Data[wordpress.DBPasswordKey] = next.Data[wordpress.NextDBPasswordKey]
+
+			if err := r.Update(ctx, secret); err != nil {
+				return err
+			}
+		}
+
+		now := metav1.Now()
+		wp.Status.Database = &wordpressv1alpha1.DatabaseStatus{
+			CredentialsRotationTime:  &now,
+			CredentialsRotationToken: token,
+			OldCredentialsRetained:   true,
+		}
+
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionUnknown,
+			wordpressv1alpha1.DBCredentialsRotatingReason, "waiting for the pods to use the new database credentials")
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.DBCredentialsRotatedReason,
+			"the database credentials have been rotated, restarting pods")
+	case job.Status.Failed > 0:
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.DBCredentialsRotationFailedReason,
+			fmt.Sprintf("job %s has failed, the database must support retaining the current password (eg. MySQL 8.0.14 or later)", job.Name))
+	default:
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionUnknown,
+			wordpressv1alpha1.DBCredentialsRotatingReason, fmt.Sprintf("waiting for job %s to complete", job.Name))
+	}
+
+	return nil
+}
+
+// discardOldDBCredentials discards the password retained by the last
+// rotation, once the web pods got rolled out with the new one.
+func (r *ReconcileWordpress) discardOldDBCredentials(ctx context.Context, wp *wordpress.Wordpress,
+	secret *corev1.Secret, deploy *appsv1.Deployment) error {
+	if wp.Status.Database == nil || !wp.Status.Database.OldCredentialsRetained {
+		return nil
+	}
+
+	if deploy == nil || !rolledOut(deploy, secret) {
+		return nil
+	}
+
+	jobSyncer := sync.NewDBCredentialsDiscardJobSyncer(wp, r.Client)
+	if err := syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case job.Status.Succeeded > 0:
+		wp.Status.Database.OldCredentialsRetained = false
+
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionTrue,
+			wordpressv1alpha1.DBCredentialsRotatedReason, "the database credentials have been rotated")
+	case job.Status.Failed > 0:
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.DBCredentialsRotationFailedReason, fmt.Sprintf("job %s has failed", job.Name))
+	default:
+		wp.SetCondition(wordpressv1alpha1.DBCredentialsRotationCondition, corev1.ConditionUnknown,
+			wordpressv1alpha1.DBCredentialsRotatingReason, fmt.Sprintf("waiting for job %s to complete", job.Name))
+	}
+
+	return nil
+}
+
+// rolledOut returns true once all the web pods were restarted with the
+// current content of the site secret.
+func rolledOut(deploy *appsv1.Deployment, secret *corev1.Secret) bool {
+	return deploy.Spec.Template.Annotations[wordpress.SecretChecksumAnnotation] == wordpress.SecretChecksum(secret) &&
+		deploy.Status.ObservedGeneration >= deploy.Generation &&
+		deploy.Status.UpdatedReplicas == deploy.Status.Replicas
+}
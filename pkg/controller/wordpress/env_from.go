@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// envFromChecksum returns the checksum of the Secrets and ConfigMaps set
+// through spec.envFrom, so the web pods get rolled when any of them changes.
+// Missing sources count as empty, so their creation rolls the pods too.
+func (r *ReconcileWordpress) envFromChecksum(ctx context.Context, wp *wordpress.Wordpress) (string, error) {
+	checksums := []string{}
+
+	for _, src := range wp.Spec.EnvFrom {
+		switch {
+		case src.SecretRef != nil:
+			secret := &corev1.Secret{}
+			key := types.NamespacedName{Name: src.SecretRef.Name, Namespace: wp.Namespace}
+
+			if err := r.referencesReader().Get(ctx, key, secret); err != nil && !errors.IsNotFound(err) {
+				return "", err
+			}
+
+			checksums = append(checksums, fmt.Sprintf("secret/%s:%s", key.Name, wordpress.SecretChecksum(secret)))
+		case src.ConfigMapRef != nil:
+			cm := &corev1.ConfigMap{}
+			key := types.NamespacedName{Name: src.ConfigMapRef.Name, Namespace: wp.Namespace}
+
+			if err := r.referencesReader().Get(ctx, key, cm); err != nil && !errors.IsNotFound(err) {
+				return "", err
+			}
+
+			checksums = append(checksums, fmt.Sprintf("configmap/%s:%s", key.Name, wordpress.ConfigMapChecksum(cm)))
+		}
+	}
+
+	return wordpress.Checksum(checksums...), nil
+}
+
+// envFromToWordpress maps the Secrets or ConfigMaps, depending on kind, to the
+// sites referencing them through spec.envFrom.
+func envFromToWordpress(c client.Client, kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		sites := &wordpressv1alpha1.WordpressList{}
+		if err := c.List(context.TODO(), sites, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+
+		for i := range sites.Items {
+			if referencesEnvFrom(sites.Items[i].Spec.EnvFrom, kind, obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      sites.Items[i].Name,
+						Namespace: sites.Items[i].Namespace,
+					},
+				})
+			}
+		}
+
+		return requests
+	}
+}
+
+func referencesEnvFrom(sources []corev1.EnvFromSource, kind, name string) bool {
+	for _, src := range sources {
+		switch kind {
+		case "Secret":
+			if src.SecretRef != nil && src.SecretRef.Name == name {
+				return true
+			}
+		case "ConfigMap":
+			if src.ConfigMapRef != nil && src.ConfigMapRef.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
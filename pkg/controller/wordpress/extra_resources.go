@@ -0,0 +1,66 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+
+	"github.com/presslabs/controller-util/syncer"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// extraResourceSyncers returns the syncers of the spec.extraResources objects.
+// The invalid ones and the ones colliding with the objects already managed by
+// the operator are skipped and reported through the returned error.
+func (r *ReconcileWordpress) extraResourceSyncers(wp *wordpress.Wordpress, managed []syncer.Interface) ([]syncer.Interface, error) {
+	if len(wp.Spec.ExtraResources) == 0 {
+		return nil, nil
+	}
+
+	refs, err := r.resourceReferences(managed)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, renderErr := wp.ExtraResources()
+
+	errs := []error{renderErr}
+	syncers := make([]syncer.Interface, 0, len(objs))
+
+	for _, obj := range objs {
+		ref := wordpressv1alpha1.ResourceReference{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+		}
+
+		if containsResourceReference(refs, ref) {
+			errs = append(errs, fmt.Errorf("%w: %s %s is already managed by the operator", wordpress.ErrInvalidExtraResource, ref.Kind, ref.Name))
+
+			continue
+		}
+
+		refs = append(refs, ref)
+		syncers = append(syncers, sync.NewExtraResourceSyncer(wp, obj, r.Client))
+	}
+
+	return syncers, utilerrors.NewAggregate(errs)
+}
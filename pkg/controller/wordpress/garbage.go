@@ -0,0 +1,145 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/presslabs/controller-util/syncer"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// resourceReferences returns the references of the objects reconciled by the syncers.
+func (r *ReconcileWordpress) resourceReferences(syncers []syncer.Interface) ([]wordpressv1alpha1.ResourceReference, error) {
+	refs := make([]wordpressv1alpha1.ResourceReference, 0, len(syncers))
+
+	for _, s := range syncers {
+		obj := s.Object().(client.Object)
+
+		gvk, err := apiutil.GVKForObject(obj, r.scheme)
+		if err != nil {
+			return nil, err
+		}
+
+		apiVersion, kind := gvk.ToAPIVersionAndKind()
+		refs = append(refs, wordpressv1alpha1.ResourceReference{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       obj.GetName(),
+		})
+	}
+
+	return refs, nil
+}
+
+// collectGarbage deletes the objects previously created for the site, which
+// are no longer reconciled, as the site spec has changed. The volume claims
+// hold the site data, so they are released instead of being deleted.
+func (r *ReconcileWordpress) collectGarbage(ctx context.Context, wp *wordpress.Wordpress, previous, current []wordpressv1alpha1.ResourceReference) error {
+	for _, ref := range previous {
+		if containsResourceReference(current, ref) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: wp.Namespace}, obj)
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// the object may have been replaced by one which is not managed by the operator
+		if !isOwnedBy(obj.GetOwnerReferences(), wp) {
+			continue
+		}
+
+		if ref.APIVersion == "v1" && ref.Kind == "PersistentVolumeClaim" {
+			if err = r.releaseVolumeClaim(ctx, wp, obj); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err = r.Delete(ctx, obj); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseVolumeClaim removes the site from the owners of a volume claim, so
+// it's kept when the site gets deleted, and leaves its deletion to the user.
+func (r *ReconcileWordpress) releaseVolumeClaim(ctx context.Context, wp *wordpress.Wordpress, obj client.Object) error {
+	refs := []metav1.OwnerReference{}
+
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID != wp.UID {
+			refs = append(refs, ref)
+		}
+	}
+
+	obj.SetOwnerReferences(refs)
+
+	if err := r.Update(ctx, obj); err != nil {
+		return err
+	}
+
+	r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.VolumeClaimReleasedReason,
+		fmt.Sprintf("PersistentVolumeClaim %s is no longer used by the site and must be deleted manually", obj.GetName()))
+
+	return nil
+}
+
+func containsResourceReference(refs []wordpressv1alpha1.ResourceReference, ref wordpressv1alpha1.ResourceReference) bool {
+	for i := range refs {
+		if refs[i] == ref {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeResourceReferences returns the references, along with the missing ones from previous.
+func mergeResourceReferences(refs, previous []wordpressv1alpha1.ResourceReference) []wordpressv1alpha1.ResourceReference {
+	for _, ref := range previous {
+		if !containsResourceReference(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/registry"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const registryTimeout = 30 * time.Second
+
+// newRegistryClient returns the client used for resolving image tags.
+var newRegistryClient = func(creds map[string]registry.Credentials) *registry.Client {
+	return &registry.Client{
+		HTTPClient:  &http.Client{Timeout: registryTimeout},
+		Credentials: creds,
+	}
+}
+
+// pinImageDigest replaces the site image tag with the digest it points to,
+// when spec.imagePolicy.pinDigest is enabled. The tag is resolved again only
+// after spec.imagePolicy.checkInterval, so the returned duration is the time
+// after which the site should be reconciled again.
+func (r *ReconcileWordpress) pinImageDigest(ctx context.Context, wp *wordpress.Wordpress) (time.Duration, error) {
+	if !wp.PinsImageDigest() {
+		wp.Status.Image = nil
+
+		return 0, nil
+	}
+
+	ref, err := registry.ParseReference(wp.Spec.Image)
+	if err != nil {
+		wp.SetCondition(wordpressv1alpha1.ImageResolvedCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.ImageResolveFailedReason, err.Error())
+
+		return 0, nil
+	}
+
+	// the image is already pinned by the user
+	if ref.Tag == "" {
+		wp.Status.Image = nil
+
+		return 0, nil
+	}
+
+	now := time.Now()
+	interval := wp.Spec.ImagePolicy.CheckInterval.Duration
+	status := wp.Status.Image
+
+	if status == nil || status.Image != wp.Spec.Image {
+		status = nil
+	}
+
+	if status != nil && status.LastCheckTime != nil && now.Before(status.LastCheckTime.Add(interval)) {
+		wp.Spec.Image = ref.WithDigest(status.Digest)
+
+		return status.LastCheckTime.Add(interval).Sub(now), nil
+	}
+
+	creds, err := r.registryCredentials(ctx, wp)
+	if err != nil {
+		return 0, err
+	}
+
+	digest, err := newRegistryClient(creds).ResolveDigest(ctx, ref)
+	if err != nil {
+		wp.SetCondition(wordpressv1alpha1.ImageResolvedCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.ImageResolveFailedReason, fmt.Sprintf("failed to resolve %s: %s", wp.Spec.Image, err))
+
+		// keep running the last known digest until the registry recovers
+		if status != nil {
+			wp.Spec.Image = ref.WithDigest(status.Digest)
+		}
+
+		return interval, nil
+	}
+
+	wp.SetCondition(wordpressv1alpha1.ImageResolvedCondition, corev1.ConditionTrue,
+		wordpressv1alpha1.ImageResolvedReason, fmt.Sprintf("%s is pinned to %s", wp.Spec.Image, digest))
+
+	checkTime := metav1.NewTime(now)
+
+	if status == nil || status.Digest != digest {
+		if status != nil {
+			r.recorder.Eventf(wp.Unwrap(), corev1.EventTypeNormal, "ImageUpdated",
+				"%s was updated from %s to %s", wp.Spec.Image, status.Digest, digest)
+		}
+
+		wp.Status.Image = &wordpressv1alpha1.ImageStatus{
+			Image:          wp.Spec.Image,
+			Digest:         digest,
+			LastUpdateTime: &checkTime,
+		}
+	}
+
+	wp.Status.Image.LastCheckTime = &checkTime
+	wp.Spec.Image = ref.WithDigest(digest)
+
+	return interval, nil
+}
+
+// registryCredentials returns the registry credentials from the site image pull secrets.
+func (r *ReconcileWordpress) registryCredentials(ctx context.Context, wp *wordpress.Wordpress) (map[string]registry.Credentials, error) {
+	creds := map[string]registry.Credentials{}
+
+	for _, ref := range wp.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+
+		key := types.NamespacedName{Name: ref.Name, Namespace: wp.Namespace}
+		if err := r.referencesReader().Get(ctx, key, secret); err != nil {
+			if ignoreNotFound(err) != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			continue
+		}
+
+		secretCreds, err := registry.CredentialsFromDockerConfig(secret.Data[corev1.DockerConfigJsonKey])
+		if err != nil {
+			return nil, fmt.Errorf("parsing image pull secret %s: %w", ref.Name, err)
+		}
+
+		for host, c := range secretCreds {
+			creds[host] = c
+		}
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// The password is set only when creating the user or when a reset is
+// requested, so the users are free to change it afterwards.
+const adminUserScript = `set -e
+if wp user get "${ADMIN_USERNAME}" --field=ID > /dev/null 2>&1; then
+    wp user update "${ADMIN_USERNAME}" --user_email="${ADMIN_EMAIL}" --role="${ADMIN_ROLE}" --skip-email
+    if [ -n "${ADMIN_PASSWORD_RESET}" ]; then
+        wp user update "${ADMIN_USERNAME}" --user_pass="${ADMIN_PASSWORD}" --skip-email
+        wp user session destroy "${ADMIN_USERNAME}" --all
+    fi
+else
+    wp user create "${ADMIN_USERNAME}" "${ADMIN_EMAIL}" --role="${ADMIN_ROLE}" --user_pass="${ADMIN_PASSWORD}"
+fi
+`
+
+// NewAdminUserJobSyncer returns a new sync.Interface for reconciling the Job which
+// creates or updates the given admin user.
+func NewAdminUserJobSyncer(wp *wordpress.Wordpress, user *wordpressv1alpha1.AdminUserSpec, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressAdminUser)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", wp.ComponentName(wordpress.WordpressAdminUser), wordpress.AdminUserToken(user)[:8]),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("AdminUserJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job spec is immutable
+			return nil
+		}
+
+		setJobLimits(&obj.Spec)
+
+		passwordRef := user.PasswordSecretRef
+
+		reset := ""
+		if wp.NeedsAdminPasswordReset(user) {
+			reset = "1"
+		}
+
+		template := wp.JobPodTemplateSpec("/bin/sh", "-c", adminUserScript)
+		template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env,
+			corev1.EnvVar{Name: "ADMIN_USERNAME", Value: user.Username},
+			corev1.EnvVar{Name: "ADMIN_EMAIL", Value: user.Email},
+			corev1.EnvVar{Name: "ADMIN_ROLE", Value: user.Role},
+			corev1.EnvVar{Name: "ADMIN_PASSWORD_RESET", Value: reset},
+			corev1.EnvVar{
+				Name:      "ADMIN_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &passwordRef},
+			},
+		)
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewCacheDropinsSyncer returns a new sync.Interface for reconciling the
+// ConfigMap holding the object cache and page cache drop-ins.
+func NewCacheDropinsSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCacheDropins)
+
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressCacheDropins),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("CacheDropins", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		obj.Data = wp.CacheDropins()
+
+		return nil
+	})
+}
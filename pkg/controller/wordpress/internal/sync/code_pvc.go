@@ -44,6 +44,7 @@ func NewCodePVCSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 
 	return syncer.NewObjectSyncer("CodePVC", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(wp.Spec.CodeVolumeSpec.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
 
 		if len(wp.Spec.CodeVolumeSpec.Annotations) > 0 {
 			obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CodeVolumeSpec.Annotations)
@@ -58,7 +59,7 @@ func NewCodePVCSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 			return nil
 		}
 
-		obj.Spec = *wp.Spec.CodeVolumeSpec.PersistentVolumeClaim
+		obj.Spec = wp.Spec.CodeVolumeSpec.PersistentVolumeClaim.PersistentVolumeClaimSpec
 
 		return nil
 	})
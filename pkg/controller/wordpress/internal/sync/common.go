@@ -16,6 +16,54 @@ limitations under the License.
 
 package sync
 
+import (
+	"errors"
+
+	batchv1 "k8s.io/api/batch/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
 var controllerLabels = map[string]string{
 	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
 }
+
+// ManagedSelector selects the objects created by the operator.
+func ManagedSelector() labels.Selector {
+	return labels.SelectorFromSet(controllerLabels)
+}
+
+// setJobLimits sets the retries and the cleanup policy of the jobs created by the operator.
+func setJobLimits(spec *batchv1.JobSpec) {
+	backoffLimit := options.JobBackoffLimit
+	ttlSecondsAfterFinished := options.JobTTLSecondsAfterFinished
+
+	spec.BackoffLimit = &backoffLimit
+	spec.TTLSecondsAfterFinished = &ttlSecondsAfterFinished
+}
+
+// IsTerminal returns true if the error is caused by the site spec or by
+// conflicting objects, so retrying is pointless until the spec changes.
+// Aggregated errors are terminal only if all of them are.
+func IsTerminal(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		for _, e := range agg.Errors() {
+			if !IsTerminal(e) {
+				return false
+			}
+		}
+
+		return len(agg.Errors()) > 0
+	}
+
+	return errors.Is(err, errImmutableDeploymentSelector) ||
+		errors.Is(err, errImmutableServiceSelector) ||
+		errors.Is(err, errForeignNamespace) ||
+		errors.Is(err, wordpress.ErrInvalidExtraResource) ||
+		k8serrors.IsInvalid(err)
+}
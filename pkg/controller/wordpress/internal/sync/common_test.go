@@ -0,0 +1,249 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/presslabs/controller-util/syncer"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bitpoke/wordpress-operator/pkg/apis"
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The IsTerminal function", func() {
+	var (
+		invalid  error
+		conflict error
+	)
+
+	BeforeEach(func() {
+		invalid = k8serrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "test", nil)
+		conflict = k8serrors.NewConflict(schema.GroupResource{Resource: "services"}, "test", nil)
+	})
+
+	It("classifies the spec errors as terminal", func() {
+		Expect(IsTerminal(fmt.Errorf("Service test: %w", errImmutableServiceSelector))).To(BeTrue())
+		Expect(IsTerminal(invalid)).To(BeTrue())
+	})
+
+	It("classifies the API errors as transient", func() {
+		Expect(IsTerminal(conflict)).To(BeFalse())
+		Expect(IsTerminal(k8serrors.NewTooManyRequests("throttled", 1))).To(BeFalse())
+	})
+
+	It("classifies aggregated errors as terminal only if all of them are", func() {
+		Expect(IsTerminal(utilerrors.NewAggregate([]error{invalid, errImmutableDeploymentSelector}))).To(BeTrue())
+		Expect(IsTerminal(utilerrors.NewAggregate([]error{invalid, conflict}))).To(BeFalse())
+	})
+})
+
+// The cache of the operator can be restricted to the objects matching the
+// ManagedSelector, so every object created by the syncers must carry its labels.
+var _ = Describe("The syncers", func() {
+	var (
+		wp *wordpress.Wordpress
+		c  client.Client
+	)
+
+	BeforeEach(func() {
+		Expect(apis.AddToScheme(scheme.Scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+		options.CacheManagedObjectsOnly = true
+		options.BlackboxExporterURL = "blackbox-exporter:9115"
+
+		storage := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+		}
+
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   "default",
+				Annotations: map[string]string{wordpress.DebugShellAnnotation: "true"},
+			},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes: []wordpressv1alpha1.RouteSpec{{Domain: "test.com"}},
+				CodeVolumeSpec: &wordpressv1alpha1.CodeVolumeSpec{
+					PersistentVolumeClaim: &wordpressv1alpha1.CodePersistentVolumeClaimSpec{
+						PersistentVolumeClaimSpec: corev1.PersistentVolumeClaimSpec{Resources: storage},
+					},
+				},
+				MediaVolumeSpec: &wordpressv1alpha1.MediaVolumeSpec{
+					S3VolumeSource:        &wordpressv1alpha1.S3VolumeSource{Bucket: "media"},
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{Resources: storage},
+				},
+				PHPConfig: map[string]string{"memory_limit": "256M"},
+				Database: &wordpressv1alpha1.DatabaseSpec{
+					ExternalSecretRef: &wordpressv1alpha1.ExternalSecretRef{StoreName: "vault", Key: "sites/test"},
+					ReadReplicas:      &wordpressv1alpha1.DatabaseReadReplicasSpec{Hosts: []string{"replica"}},
+				},
+				Autoscaling: &wordpressv1alpha1.AutoscalingSpec{
+					Vertical: &wordpressv1alpha1.VerticalAutoscalingSpec{},
+					KEDA: &wordpressv1alpha1.KEDAAutoscalingSpec{
+						MaxReplicas: 3,
+						Triggers:    []wordpressv1alpha1.KEDATrigger{{Type: "cpu"}},
+					},
+				},
+				Metrics: &wordpressv1alpha1.MetricsSpec{
+					Enabled:        true,
+					ServiceMonitor: &wordpressv1alpha1.ServiceMonitorSpec{},
+					Dashboard:      &wordpressv1alpha1.GrafanaDashboardSpec{},
+					Alerts:         &wordpressv1alpha1.AlertsSpec{},
+				},
+				MediaGC:       &wordpressv1alpha1.MediaGCSpec{},
+				DBMaintenance: &wordpressv1alpha1.DBMaintenanceSpec{},
+				DeveloperAccess: &wordpressv1alpha1.DeveloperAccessSpec{
+					SFTP: &wordpressv1alpha1.SFTPSpec{
+						AuthorizedKeysSecretRef: corev1.LocalObjectReference{Name: "keys"},
+					},
+					WebDAV: &wordpressv1alpha1.WebDAVSpec{
+						CredentialsSecretRef: corev1.LocalObjectReference{Name: "webdav"},
+						Domain:               "webdav.test.com",
+					},
+				},
+				AdminUsers: []wordpressv1alpha1.AdminUserSpec{
+					{Username: "admin", Email: "admin@test.com"},
+				},
+			},
+		})
+		wp.SetDefaults()
+	})
+
+	AfterEach(func() {
+		options.CacheManagedObjectsOnly = false
+		options.BlackboxExporterURL = ""
+	})
+
+	DescribeTable("label the objects they create as managed by the operator",
+		func(newSyncers func() []syncer.Interface) {
+			syncers := newSyncers()
+			Expect(syncers).NotTo(BeEmpty())
+
+			for _, s := range syncers {
+				Expect(syncer.Sync(context.TODO(), s, record.NewFakeRecorder(10))).To(Succeed())
+
+				obj := s.Object().(client.Object)
+				Expect(ManagedSelector().Matches(labels.Set(obj.GetLabels()))).To(BeTrue(),
+					"%T %s is missing the managed-by label", obj, obj.GetName())
+			}
+		},
+		Entry("site secret", func() []syncer.Interface { return one(NewSecretSyncer(wp, c)) }),
+		Entry("deployment", func() []syncer.Interface {
+			return one(NewDeploymentSyncer(wp, &corev1.Secret{}, nil, "", false, c))
+		}),
+		Entry("service", func() []syncer.Interface { return one(NewServiceSyncer(wp, c)) }),
+		Entry("ingress", func() []syncer.Interface { return one(NewIngressSyncer(wp, c)) }),
+		Entry("code pvc", func() []syncer.Interface { return one(NewCodePVCSyncer(wp, c)) }),
+		Entry("media pvc", func() []syncer.Interface { return one(NewMediaPVCSyncer(wp, c)) }),
+		Entry("media bucket", func() []syncer.Interface { return NewMediaBucketSyncers(wp, c) }),
+		Entry("php config", func() []syncer.Interface { return one(NewPHPConfigSyncer(wp, c)) }),
+		Entry("db config", func() []syncer.Interface { return one(NewDBConfigSyncer(wp, c)) }),
+		Entry("cache dropins", func() []syncer.Interface { return one(NewCacheDropinsSyncer(wp, c)) }),
+		Entry("nginx config", func() []syncer.Interface { return one(NewNginxConfigSyncer(wp, c)) }),
+		Entry("logging config", func() []syncer.Interface { return one(NewLoggingConfigSyncer(wp, c)) }),
+		Entry("external secret", func() []syncer.Interface { return one(NewExternalSecretSyncer(wp, c)) }),
+		Entry("db credentials secret", func() []syncer.Interface { return one(NewDBCredentialsSecretSyncer(wp, c)) }),
+		Entry("db credentials rotation job", func() []syncer.Interface {
+			return one(NewDBCredentialsRotationJobSyncer(wp, c))
+		}),
+		Entry("db credentials discard job", func() []syncer.Interface {
+			return one(NewDBCredentialsDiscardJobSyncer(wp, c))
+		}),
+		Entry("service account", func() []syncer.Interface { return one(NewServiceAccountSyncer(wp, c)) }),
+		Entry("service monitor", func() []syncer.Interface { return one(NewServiceMonitorSyncer(wp, c)) }),
+		Entry("grafana dashboard", func() []syncer.Interface { return one(NewGrafanaDashboardSyncer(wp, c)) }),
+		Entry("prometheus rule", func() []syncer.Interface { return one(NewPrometheusRuleSyncer(wp, c)) }),
+		Entry("probe", func() []syncer.Interface { return one(NewProbeSyncer(wp, c)) }),
+		Entry("media gc cron job", func() []syncer.Interface { return one(NewMediaGCCronJobSyncer(wp, c)) }),
+		Entry("db maintenance cron job", func() []syncer.Interface {
+			return one(NewDBMaintenanceCronJobSyncer(wp, c))
+		}),
+		Entry("sftp service", func() []syncer.Interface { return one(NewSFTPServiceSyncer(wp, c)) }),
+		Entry("webdav", func() []syncer.Interface { return NewWebDAVSyncers(wp, c) }),
+		Entry("debug shell pod", func() []syncer.Interface { return one(NewDebugShellPodSyncer(wp, c)) }),
+		Entry("scaled object", func() []syncer.Interface { return one(NewScaledObjectSyncer(wp, c)) }),
+		Entry("vertical pod autoscaler", func() []syncer.Interface { return one(NewVPASyncer(wp, c)) }),
+		Entry("admin user job", func() []syncer.Interface {
+			return one(NewAdminUserJobSyncer(wp, &wp.Spec.AdminUsers[0], c))
+		}),
+		Entry("content export job", func() []syncer.Interface { return one(NewContentExportJobSyncer(wp, c)) }),
+		Entry("content import job", func() []syncer.Interface { return one(NewContentImportJobSyncer(wp, c)) }),
+		Entry("db upgrade job", func() []syncer.Interface { return one(NewDBUpgradeJobSyncer(wp, c)) }),
+		Entry("extra resource", func() []syncer.Interface {
+			desired := &unstructured.Unstructured{}
+			desired.SetAPIVersion("v1")
+			desired.SetKind("ConfigMap")
+			desired.SetName("test-extra")
+			desired.SetNamespace(wp.Namespace)
+
+			return one(NewExtraResourceSyncer(wp, desired, c))
+		}),
+		Entry("provisioned namespace", func() []syncer.Interface { return one(NewProvisionedNamespaceSyncer(wp, c)) }),
+		Entry("provisioned resource quota", func() []syncer.Interface {
+			return one(NewProvisionedResourceQuotaSyncer(wp, &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "quota"},
+			}, c))
+		}),
+		Entry("provisioned limit range", func() []syncer.Interface {
+			return one(NewProvisionedLimitRangeSyncer(wp, &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Name: "limits"},
+			}, c))
+		}),
+		Entry("provisioned network policy", func() []syncer.Interface {
+			return one(NewProvisionedNetworkPolicySyncer(wp, &netv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			}, c))
+		}),
+	)
+
+	It("labels the database secret materialized by the ExternalSecret as managed by the operator", func() {
+		s := NewExternalSecretSyncer(wp, c)
+		Expect(syncer.Sync(context.TODO(), s, record.NewFakeRecorder(10))).To(Succeed())
+
+		obj := s.Object().(*unstructured.Unstructured)
+		secretLabels, found, err := unstructured.NestedStringMap(obj.Object, "spec", "target", "template", "metadata", "labels")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(ManagedSelector().Matches(labels.Set(secretLabels))).To(BeTrue())
+	})
+})
+
+func one(s syncer.Interface) []syncer.Interface {
+	return []syncer.Interface{s}
+}
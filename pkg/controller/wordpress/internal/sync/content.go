@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const exportContentScript = `set -e
+wp export --stdout > /tmp/content.xml
+curl -fsS -X PUT -H "Content-Type: application/xml" --upload-file /tmp/content.xml "${CONTENT_URL}"
+`
+
+// The WordPress Importer plugin needs to be available in the site code. It
+// gets activated only for the duration of the import.
+const importContentScript = `set -e
+curl -fsSL -o /tmp/content.xml "${CONTENT_URL}"
+if ! wp plugin is-active wordpress-importer; then
+    wp plugin activate wordpress-importer
+    trap 'wp plugin deactivate wordpress-importer' EXIT
+fi
+wp import /tmp/content.xml --authors=create
+`
+
+// NewContentExportJobSyncer returns a new sync.Interface for reconciling the Job which
+// exports the site content as WXR to the URL set through the export-content annotation.
+func NewContentExportJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressContentExport)
+	objName := wp.ComponentName(wordpress.WordpressContentExport)
+
+	return newContentTransferJobSyncer("ContentExportJob", objName, objLabels, exportContentScript, wp.PendingContentExport(), wp, c)
+}
+
+// NewContentImportJobSyncer returns a new sync.Interface for reconciling the Job which
+// imports into the site the WXR file from the URL set through the import-content annotation.
+func NewContentImportJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressContentImport)
+	objName := wp.ComponentName(wordpress.WordpressContentImport)
+
+	return newContentTransferJobSyncer("ContentImportJob", objName, objLabels, importContentScript, wp.PendingContentImport(), wp, c)
+}
+
+func newContentTransferJobSyncer(name, objName string, objLabels labels.Set, script, url string,
+	wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objName,
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer(name, wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job spec is immutable
+			return nil
+		}
+
+		setJobLimits(&obj.Spec)
+
+		template := wp.JobPodTemplateSpec("/bin/sh", "-c", script)
+		template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "CONTENT_URL",
+			Value: url,
+		})
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
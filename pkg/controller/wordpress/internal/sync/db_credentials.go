@@ -0,0 +1,155 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/rand"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// dbPasswordLength is the length of the database passwords generated on rotation.
+const dbPasswordLength = 32
+
+// The current password is retained, so that the pods which weren't restarted
+// yet can still connect. The rotation fails on the servers without dual
+// passwords support, instead of locking out the running pods.
+const rotateDBCredentialsScript = `wp db query "ALTER USER CURRENT_USER() IDENTIFIED BY '${NEXT_DB_PASSWORD}' RETAIN CURRENT PASSWORD"`
+
+const discardDBCredentialsScript = `wp db query "ALTER USER CURRENT_USER() DISCARD OLD PASSWORD"`
+
+// NewDBCredentialsSecretSyncer returns a new sync.Interface for reconciling the Secret holding
+// the database password pending rotation. The secret is read only by the rotation job.
+func NewDBCredentialsSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDBCredentialsSecret)
+
+	obj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressDBCredentialsSecret),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	token := wp.PendingDBCredentialsRotation()
+
+	return syncer.NewObjectSyncer("DBCredentialsSecret", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		// a new password is generated for every rotation
+		if obj.Annotations[wordpress.RotateDBCredentialsAnnotation] == token && len(obj.Data[wordpress.NextDBPasswordKey]) > 0 {
+			return nil
+		}
+
+		random, err := rand.AlphaNumericString(dbPasswordLength)
+		if err != nil {
+			return err
+		}
+
+		obj.Data = map[string][]byte{
+			wordpress.NextDBPasswordKey: []byte(random),
+		}
+		obj.Annotations = labels.Merge(obj.Annotations, map[string]string{
+			wordpress.RotateDBCredentialsAnnotation: token,
+		})
+
+		return nil
+	})
+}
+
+// NewDBCredentialsRotationJobSyncer returns a new sync.Interface for reconciling the Job which
+// changes the database user password to the one pending rotation.
+func NewDBCredentialsRotationJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDBCredentialsRotation)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressDBCredentialsRotation),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("DBCredentialsRotationJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job spec is immutable
+			return nil
+		}
+
+		setJobLimits(&obj.Spec)
+
+		template := wp.JobPodTemplateSpec("/bin/sh", "-c", rotateDBCredentialsScript)
+		template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+			Name: "NEXT_DB_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.ComponentName(wordpress.WordpressDBCredentialsSecret),
+					},
+					Key: wordpress.NextDBPasswordKey,
+				},
+			},
+		})
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+// NewDBCredentialsDiscardJobSyncer returns a new sync.Interface for reconciling the Job which
+// discards the database password retained by the last rotation.
+func NewDBCredentialsDiscardJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDBCredentialsDiscard)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressDBCredentialsDiscard),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("DBCredentialsDiscardJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job spec is immutable
+			return nil
+		}
+
+		setJobLimits(&obj.Spec)
+
+		template := wp.JobPodTemplateSpec("/bin/sh", "-c", discardDBCredentialsScript)
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
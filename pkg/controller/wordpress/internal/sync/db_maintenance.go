@@ -0,0 +1,91 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"strconv"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const dbMaintenanceScript = `set -e
+if [ "${DELETE_ALL_TRANSIENTS}" = "true" ]; then
+    wp transient delete --all
+else
+    wp transient delete --expired
+fi
+wp db optimize
+`
+
+var errDBMaintenanceNotDefined = errors.New(".spec.dbMaintenance is not defined")
+
+// NewDBMaintenanceCronJobSyncer returns a new sync.Interface for reconciling the
+// CronJob which cleans up the transients and optimizes the database tables.
+func NewDBMaintenanceCronJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDBMaintenance)
+
+	obj := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressDBMaintenance),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("DBMaintenanceCronJob", wp.Unwrap(), obj, c, func() error {
+		if !wp.HasDBMaintenance() {
+			return errDBMaintenanceNotDefined
+		}
+
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		obj.Spec.Schedule = wp.Spec.DBMaintenance.Schedule
+		obj.Spec.ConcurrencyPolicy = wp.Spec.DBMaintenance.ConcurrencyPolicy
+		obj.Spec.StartingDeadlineSeconds = wp.Spec.DBMaintenance.StartingDeadlineSeconds
+
+		suspend := wp.Spec.DBMaintenance.Suspend
+		obj.Spec.Suspend = &suspend
+
+		obj.Spec.SuccessfulJobsHistoryLimit = wp.Spec.DBMaintenance.SuccessfulJobsHistoryLimit
+		obj.Spec.FailedJobsHistoryLimit = wp.Spec.DBMaintenance.FailedJobsHistoryLimit
+
+		obj.Spec.JobTemplate.ObjectMeta.Labels = objLabels
+		setJobLimits(&obj.Spec.JobTemplate.Spec)
+
+		template := wp.CronJobPodTemplateSpec("/bin/sh", "-c", dbMaintenanceScript)
+		template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "DELETE_ALL_TRANSIENTS",
+			Value: strconv.FormatBool(wp.Spec.DBMaintenance.DeleteAllTransients),
+		})
+
+		obj.Spec.JobTemplate.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.JobTemplate.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
@@ -37,7 +37,11 @@ import (
 var errImmutableDeploymentSelector = errors.New("deployment selector is immutable")
 
 // NewDeploymentSyncer returns a new sync.Interface for reconciling web Deployment.
-func NewDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, c client.Client) syncer.Interface {
+// The dbSecret is the Secret holding the database credentials, if it's managed externally.
+// The envFromChecksum is the checksum of the spec.envFrom sources.
+// If autoscaled is true, the replicas are left to be managed by the autoscaler.
+func NewDeploymentSyncer(wp *wordpress.Wordpress, secret, dbSecret *corev1.Secret, envFromChecksum string,
+	autoscaled bool, c client.Client) syncer.Interface {
 	objLabels := wp.ComponentLabels(wordpress.WordpressDeployment)
 
 	obj := &appsv1.Deployment{
@@ -49,13 +53,24 @@ func NewDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, c clien
 
 	return syncer.NewObjectSyncer("Deployment", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
 
 		template := wp.WebPodTemplateSpec()
 
 		if len(template.Annotations) == 0 {
 			template.Annotations = make(map[string]string)
 		}
-		template.Annotations["wordpress.presslabs.org/secretVersion"] = secret.ResourceVersion
+
+		// roll the pods when the values of the environment variables change
+		template.Annotations[wordpress.SecretChecksumAnnotation] = wordpress.SecretChecksum(secret)
+
+		if dbSecret != nil {
+			template.Annotations[wordpress.DBSecretChecksumAnnotation] = wordpress.SecretChecksum(dbSecret)
+		}
+
+		if len(wp.Spec.EnvFrom) > 0 {
+			template.Annotations[wordpress.EnvFromChecksumAnnotation] = envFromChecksum
+		}
 
 		obj.Spec.Template.ObjectMeta = template.ObjectMeta
 
@@ -73,15 +88,30 @@ func NewDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, c clien
 			return err
 		}
 
-		obj.Spec.Template.Spec.NodeSelector = wp.Spec.NodeSelector
-		obj.Spec.Template.Spec.Tolerations = wp.Spec.Tolerations
+		obj.Spec.Template.Spec.NodeSelector = template.Spec.NodeSelector
+		obj.Spec.Template.Spec.Tolerations = template.Spec.Tolerations
+		obj.Spec.Template.Spec.SecurityContext = template.Spec.SecurityContext
+		obj.Spec.Template.Spec.TerminationGracePeriodSeconds = template.Spec.TerminationGracePeriodSeconds
+		obj.Spec.Template.Spec.HostAliases = template.Spec.HostAliases
+		obj.Spec.Template.Spec.DNSPolicy = template.Spec.DNSPolicy
+		obj.Spec.Template.Spec.DNSConfig = template.Spec.DNSConfig
 
-		if wp.Spec.Replicas != nil {
+		if wp.Spec.Replicas != nil && !autoscaled {
 			obj.Spec.Replicas = wp.Spec.Replicas
 		}
 
+		obj.Spec.RevisionHistoryLimit = wp.Spec.RevisionHistoryLimit
+		obj.Spec.ProgressDeadlineSeconds = wp.Spec.ProgressDeadlineSeconds
+
+		if wp.Spec.MinReadySeconds != nil {
+			obj.Spec.MinReadySeconds = *wp.Spec.MinReadySeconds
+		}
+
 		if wp.Spec.DeploymentStrategy != nil {
 			obj.Spec.Strategy = *wp.Spec.DeploymentStrategy
+		} else if obj.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType {
+			// revert to the default strategy once it's unset
+			obj.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
 		}
 
 		return nil
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errExternalSecretRefNotDefined = errors.New(".spec.database.externalSecretRef is not defined")
+
+// NewExternalSecretSyncer returns a new sync.Interface for reconciling the
+// ExternalSecret which materializes the database credentials.
+func NewExternalSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressExternalSecret)
+	secretLabels := wp.ComponentLabels(wordpress.WordpressDatabaseSecret)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("external-secrets.io/v1beta1")
+	obj.SetKind("ExternalSecret")
+	obj.SetName(wp.ComponentName(wordpress.WordpressExternalSecret))
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("ExternalSecret", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels))
+		obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		if !wp.HasExternalDatabaseSecret() {
+			return errExternalSecretRefNotDefined
+		}
+
+		ref := wp.Spec.Database.ExternalSecretRef
+
+		spec := map[string]interface{}{
+			"refreshInterval": ref.RefreshInterval.Duration.String(),
+			"secretStoreRef": map[string]interface{}{
+				"name": ref.StoreName,
+				"kind": ref.StoreKind,
+			},
+			"target": map[string]interface{}{
+				"name":           wp.ComponentName(wordpress.WordpressDatabaseSecret),
+				"creationPolicy": "Owner",
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						// the labels are used to map the secret back to the site
+						"labels": toUnstructuredMap(labels.Merge(secretLabels, controllerLabels)),
+					},
+				},
+			},
+			"dataFrom": []interface{}{
+				map[string]interface{}{
+					"extract": map[string]interface{}{
+						"key": ref.Key,
+					},
+				},
+			},
+		}
+
+		return unstructured.SetNestedMap(obj.Object, spec, "spec")
+	})
+}
+
+func toUnstructuredMap(in map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+
+	return out
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// extraResourceKeptKeys are the top level keys of the extra resources which
+// are not taken from the rendered manifest.
+var extraResourceKeptKeys = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"metadata":   true,
+	"status":     true,
+}
+
+// NewExtraResourceSyncer returns a new sync.Interface for reconciling an
+// object rendered from spec.extraResources.
+func NewExtraResourceSyncer(wp *wordpress.Wordpress, desired *unstructured.Unstructured, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressExtraResource)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(desired.GetAPIVersion())
+	obj.SetKind(desired.GetKind())
+	obj.SetName(desired.GetName())
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("ExtraResource", wp.Unwrap(), obj, c, func() error {
+		// the content is replaced, so the keys removed from the manifest get
+		// removed from the object too
+		content := map[string]interface{}{}
+
+		for key, value := range obj.Object {
+			if extraResourceKeptKeys[key] {
+				content[key] = value
+			}
+		}
+
+		for key, value := range desired.Object {
+			if !extraResourceKeptKeys[key] {
+				content[key] = runtime.DeepCopyJSONValue(value)
+			}
+		}
+
+		obj.Object = content
+
+		obj.SetLabels(labels.Merge(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), desired.GetLabels()), controllerLabels))
+		obj.SetAnnotations(labels.Merge(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations), desired.GetAnnotations()))
+
+		return nil
+	})
+}
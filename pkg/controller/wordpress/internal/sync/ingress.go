@@ -92,11 +92,26 @@ func NewIngressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 
 	return syncer.NewObjectSyncer("Ingress", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
 
 		if len(obj.ObjectMeta.Annotations) == 0 {
 			obj.ObjectMeta.Annotations = make(map[string]string)
 		}
 
+		// drop the affinity annotations when sticky sessions get disabled,
+		// unless they are explicitly set by the user
+		for _, k := range []string{
+			wordpress.IngressAffinityAnnotation,
+			wordpress.IngressSessionCookieNameAnnotation,
+			wordpress.IngressSessionCookieMaxAgeAnnotation,
+		} {
+			delete(obj.ObjectMeta.Annotations, k)
+		}
+
+		for k, v := range wp.StickySessionsIngressAnnotations() {
+			obj.ObjectMeta.Annotations[k] = v
+		}
+
 		for k, v := range wp.Spec.IngressAnnotations {
 			obj.ObjectMeta.Annotations[k] = v
 		}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// BucketProvisioner provisions the media bucket of a site.
+type BucketProvisioner interface {
+	// Syncers returns the syncers for the objects which provision the bucket
+	// and grant the site access to it.
+	Syncers(wp *wordpress.Wordpress, c client.Client) []syncer.Interface
+}
+
+var (
+	// S3BucketProvisioner is used for provisioning the S3 media buckets.
+	S3BucketProvisioner BucketProvisioner = crossplaneBucketProvisioner{}
+
+	// GCSBucketProvisioner is used for provisioning the GCS media buckets.
+	GCSBucketProvisioner BucketProvisioner = configConnectorBucketProvisioner{}
+)
+
+// NewMediaBucketSyncers returns the syncers for provisioning the media bucket.
+func NewMediaBucketSyncers(wp *wordpress.Wordpress, c client.Client) []syncer.Interface {
+	switch {
+	case wp.Spec.MediaVolumeSpec.S3VolumeSource != nil:
+		return S3BucketProvisioner.Syncers(wp, c)
+	case wp.Spec.MediaVolumeSpec.GCSVolumeSource != nil:
+		return GCSBucketProvisioner.Syncers(wp, c)
+	}
+
+	return nil
+}
+
+// crossplaneBucketProvisioner provisions S3 buckets using the Crossplane AWS provider
+type crossplaneBucketProvisioner struct{}
+
+func (crossplaneBucketProvisioner) Syncers(wp *wordpress.Wordpress, c client.Client) []syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMediaBucket)
+	bucket := wp.Spec.MediaVolumeSpec.S3VolumeSource.Bucket
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("s3.aws.crossplane.io/v1beta1")
+	obj.SetKind("Bucket")
+	obj.SetName(bucket)
+
+	// Crossplane managed resources are cluster scoped, so they can't be owned by the site
+	return []syncer.Interface{
+		syncer.NewObjectSyncer("S3Bucket", nil, obj, c, func() error {
+			obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels))
+			obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+			spec := map[string]interface{}{
+				"deletionPolicy": "Orphan",
+				"forProvider": map[string]interface{}{
+					"locationConstraint": options.S3BucketRegion,
+				},
+				"providerConfigRef": map[string]interface{}{
+					"name": options.CrossplaneProviderConfig,
+				},
+			}
+
+			return unstructured.SetNestedMap(obj.Object, spec, "spec")
+		}),
+	}
+}
+
+// configConnectorBucketProvisioner provisions GCS buckets using Config Connector
+type configConnectorBucketProvisioner struct{}
+
+func (configConnectorBucketProvisioner) Syncers(wp *wordpress.Wordpress, c client.Client) []syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMediaBucket)
+	gcs := wp.Spec.MediaVolumeSpec.GCSVolumeSource
+
+	bucket := &unstructured.Unstructured{}
+	bucket.SetAPIVersion("storage.cnrm.cloud.google.com/v1beta1")
+	bucket.SetKind("StorageBucket")
+	bucket.SetName(gcs.Bucket)
+	bucket.SetNamespace(wp.Namespace)
+
+	syncers := []syncer.Interface{
+		syncer.NewObjectSyncer("StorageBucket", wp.Unwrap(), bucket, c, func() error {
+			bucket.SetLabels(labels.Merge(labels.Merge(bucket.GetLabels(), objLabels), controllerLabels))
+
+			// keep the bucket and the media files when the site is deleted
+			annotations := labels.Merge(bucket.GetAnnotations(), wp.Spec.CommonAnnotations)
+			annotations["cnrm.cloud.google.com/deletion-policy"] = "abandon"
+			bucket.SetAnnotations(annotations)
+
+			return unstructured.SetNestedField(bucket.Object, true, "spec", "uniformBucketLevelAccess")
+		}),
+	}
+
+	if len(gcs.ServiceAccount) == 0 {
+		return syncers
+	}
+
+	member := &unstructured.Unstructured{}
+	member.SetAPIVersion("iam.cnrm.cloud.google.com/v1beta1")
+	member.SetKind("IAMPolicyMember")
+	member.SetName(wp.ComponentName(wordpress.WordpressMediaBucket))
+	member.SetNamespace(wp.Namespace)
+
+	return append(syncers, syncer.NewObjectSyncer("IAMPolicyMember", wp.Unwrap(), member, c, func() error {
+		member.SetLabels(labels.Merge(labels.Merge(member.GetLabels(), objLabels), controllerLabels))
+		member.SetAnnotations(labels.Merge(member.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		spec := map[string]interface{}{
+			"member": "serviceAccount:" + gcs.ServiceAccount,
+			"role":   "roles/storage.objectAdmin",
+			"resourceRef": map[string]interface{}{
+				"apiVersion": "storage.cnrm.cloud.google.com/v1beta1",
+				"kind":       "StorageBucket",
+				"name":       gcs.Bucket,
+			},
+		}
+
+		return unstructured.SetNestedMap(member.Object, spec, "spec")
+	}))
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"strconv"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// mediaGCScript is evaluated by wp-cli. Only the files in the year/month
+// upload directories are considered, as the other ones are usually managed
+// by plugins. The files of an attachment are the attached file, its resized
+// versions and the original image of the scaled down ones.
+const mediaGCScript = `
+$basedir = wp_upload_dir()['basedir'];
+$dry_run = getenv('DRY_RUN') === 'true';
+$known = array();
+
+$ids = get_posts(array('post_type' => 'attachment', 'post_status' => 'any', 'numberposts' => -1, 'fields' => 'ids'));
+foreach ($ids as $id) {
+    $file = get_attached_file($id, true);
+    if (!$file) {
+        continue;
+    }
+    $known[$file] = true;
+
+    $meta = wp_get_attachment_metadata($id);
+    $dir = dirname($file);
+    foreach (isset($meta['sizes']) ? $meta['sizes'] : array() as $size) {
+        $known[$dir . '/' . $size['file']] = true;
+    }
+    if (!empty($meta['original_image'])) {
+        $known[$dir . '/' . $meta['original_image']] = true;
+    }
+}
+
+$orphans = 0;
+$files = new RecursiveIteratorIterator(new RecursiveDirectoryIterator($basedir, FilesystemIterator::SKIP_DOTS));
+foreach ($files as $f) {
+    $path = $f->getPathname();
+    if (!preg_match('#^/\d{4}/\d{2}/[^/]+$#', substr($path, strlen($basedir))) || isset($known[$path])) {
+        continue;
+    }
+
+    $orphans++;
+    if ($dry_run) {
+        WP_CLI::log("orphaned: $path");
+    } elseif (unlink($path)) {
+        WP_CLI::log("removed: $path");
+    } else {
+        WP_CLI::warning("failed to remove: $path");
+    }
+}
+
+WP_CLI::success(sprintf('%d orphaned media files %s', $orphans, $dry_run ? 'found' : 'removed'));
+`
+
+var errMediaGCNotDefined = errors.New(".spec.mediaGC is not defined")
+
+// NewMediaGCCronJobSyncer returns a new sync.Interface for reconciling the
+// CronJob which collects the orphaned media files.
+func NewMediaGCCronJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMediaGC)
+
+	obj := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressMediaGC),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("MediaGCCronJob", wp.Unwrap(), obj, c, func() error {
+		if !wp.HasMediaGC() {
+			return errMediaGCNotDefined
+		}
+
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		obj.Spec.Schedule = wp.Spec.MediaGC.Schedule
+		obj.Spec.ConcurrencyPolicy = wp.Spec.MediaGC.ConcurrencyPolicy
+		obj.Spec.StartingDeadlineSeconds = wp.Spec.MediaGC.StartingDeadlineSeconds
+
+		suspend := wp.Spec.MediaGC.Suspend
+		obj.Spec.Suspend = &suspend
+
+		obj.Spec.SuccessfulJobsHistoryLimit = wp.Spec.MediaGC.SuccessfulJobsHistoryLimit
+		obj.Spec.FailedJobsHistoryLimit = wp.Spec.MediaGC.FailedJobsHistoryLimit
+
+		obj.Spec.JobTemplate.ObjectMeta.Labels = objLabels
+		setJobLimits(&obj.Spec.JobTemplate.Spec)
+
+		template := wp.CronJobPodTemplateSpec("wp", "eval", mediaGCScript)
+		template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "DRY_RUN",
+			Value: strconv.FormatBool(wp.Spec.MediaGC.DryRun),
+		})
+
+		obj.Spec.JobTemplate.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.JobTemplate.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
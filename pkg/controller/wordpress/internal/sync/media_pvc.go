@@ -44,6 +44,7 @@ func NewMediaPVCSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interfac
 
 	return syncer.NewObjectSyncer("MediaPVC", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(wp.Spec.MediaVolumeSpec.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
 
 		if len(wp.Spec.MediaVolumeSpec.Annotations) > 0 {
 			obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.MediaVolumeSpec.Annotations)
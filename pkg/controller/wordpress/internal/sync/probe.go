@@ -0,0 +1,77 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errProbeNotDefined = errors.New("the blackbox exporter is not configured or the site is internal")
+
+// NewProbeSyncer returns a new sync.Interface for reconciling the Prometheus
+// Operator Probe checking the site routes through the blackbox exporter.
+func NewProbeSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressProbe)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("monitoring.coreos.com/v1")
+	obj.SetKind("Probe")
+	obj.SetName(wp.ComponentName(wordpress.WordpressProbe))
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("Probe", wp.Unwrap(), obj, c, func() error {
+		if !wp.HasProbe() {
+			return errProbeNotDefined
+		}
+
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels))
+		obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		targets := []interface{}{}
+		for _, target := range wp.ProbeTargets() {
+			targets = append(targets, target)
+		}
+
+		spec := map[string]interface{}{
+			"jobName": "wordpress-probe",
+			"module":  options.BlackboxExporterModule,
+			"prober": map[string]interface{}{
+				"url": options.BlackboxExporterURL,
+			},
+			"targets": map[string]interface{}{
+				"staticConfig": map[string]interface{}{
+					"static": targets,
+					"labels": map[string]interface{}{
+						"namespace": wp.Namespace,
+						"wordpress": wp.Name,
+					},
+				},
+			},
+		}
+
+		return unstructured.SetNestedMap(obj.Object, spec, "spec")
+	})
+}
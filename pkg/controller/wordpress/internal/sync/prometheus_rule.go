@@ -0,0 +1,79 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errAlertsNotDefined = errors.New(".spec.metrics.alerts is not defined")
+
+// NewPrometheusRuleSyncer returns a new sync.Interface for reconciling the
+// Prometheus Operator PrometheusRule holding the site alerts.
+func NewPrometheusRuleSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressPrometheusRule)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("monitoring.coreos.com/v1")
+	obj.SetKind("PrometheusRule")
+	obj.SetName(wp.ComponentName(wordpress.WordpressPrometheusRule))
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("PrometheusRule", wp.Unwrap(), obj, c, func() error {
+		if !wp.HasAlerts() {
+			return errAlertsNotDefined
+		}
+
+		obj.SetLabels(labels.Merge(labels.Merge(labels.Merge(obj.GetLabels(), wp.Spec.Metrics.Alerts.Labels), objLabels), controllerLabels))
+		obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		rules := []interface{}{}
+		for _, rule := range wp.AlertRules() {
+			rules = append(rules, map[string]interface{}{
+				"alert": rule.Alert,
+				"expr":  rule.Expr,
+				"for":   rule.For,
+				"labels": map[string]interface{}{
+					"severity": rule.Severity,
+				},
+				"annotations": map[string]interface{}{
+					"summary":     rule.Summary,
+					"description": rule.Description,
+				},
+			})
+		}
+
+		spec := map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  "wordpress",
+					"rules": rules,
+				},
+			},
+		}
+
+		return unstructured.SetNestedMap(obj.Object, spec, "spec")
+	})
+}
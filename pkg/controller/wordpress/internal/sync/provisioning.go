@@ -0,0 +1,140 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errForeignNamespace = errors.New("namespace is not provisioned for this site")
+
+// The provisioned objects live outside of the site namespace, so they can't
+// be owned by the site. They are labeled with the site name instead.
+func provisionedLabels(wp *wordpress.Wordpress) map[string]string {
+	return labels.Merge(controllerLabels, map[string]string{
+		wordpress.BlueprintLabel: wp.Name,
+	})
+}
+
+// NewProvisionedNamespaceSyncer returns a new sync.Interface for reconciling
+// the namespace provisioned for a site created in the blueprint namespace.
+func NewProvisionedNamespaceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	obj := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: wp.ProvisionedNamespace(),
+		},
+	}
+
+	return syncer.NewObjectSyncer("ProvisionedNamespace", nil, obj, c, func() error {
+		if !obj.CreationTimestamp.IsZero() && obj.Labels[wordpress.BlueprintLabel] != wp.Name {
+			return fmt.Errorf("%w: %s", errForeignNamespace, obj.Name)
+		}
+
+		obj.Labels = labels.Merge(obj.Labels, provisionedLabels(wp))
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		return nil
+	})
+}
+
+// NewProvisionedResourceQuotaSyncer returns a new sync.Interface for
+// reconciling a copy of a blueprint ResourceQuota in the provisioned namespace.
+func NewProvisionedResourceQuotaSyncer(wp *wordpress.Wordpress, blueprint *corev1.ResourceQuota, c client.Client) syncer.Interface {
+	obj := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      blueprint.Name,
+			Namespace: wp.ProvisionedNamespace(),
+		},
+	}
+
+	return syncer.NewObjectSyncer("ProvisionedResourceQuota", nil, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, blueprint.Labels), provisionedLabels(wp))
+		obj.Spec = *blueprint.Spec.DeepCopy()
+
+		return nil
+	})
+}
+
+// NewProvisionedLimitRangeSyncer returns a new sync.Interface for
+// reconciling a copy of a blueprint LimitRange in the provisioned namespace.
+func NewProvisionedLimitRangeSyncer(wp *wordpress.Wordpress, blueprint *corev1.LimitRange, c client.Client) syncer.Interface {
+	obj := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      blueprint.Name,
+			Namespace: wp.ProvisionedNamespace(),
+		},
+	}
+
+	return syncer.NewObjectSyncer("ProvisionedLimitRange", nil, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, blueprint.Labels), provisionedLabels(wp))
+		obj.Spec = *blueprint.Spec.DeepCopy()
+
+		return nil
+	})
+}
+
+// NewProvisionedNetworkPolicySyncer returns a new sync.Interface for
+// reconciling a copy of a blueprint NetworkPolicy in the provisioned namespace.
+func NewProvisionedNetworkPolicySyncer(wp *wordpress.Wordpress, blueprint *netv1.NetworkPolicy, c client.Client) syncer.Interface {
+	obj := &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      blueprint.Name,
+			Namespace: wp.ProvisionedNamespace(),
+		},
+	}
+
+	return syncer.NewObjectSyncer("ProvisionedNetworkPolicy", nil, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, blueprint.Labels), provisionedLabels(wp))
+		obj.Spec = *blueprint.Spec.DeepCopy()
+
+		return nil
+	})
+}
+
+// NewProvisionedWordpressSyncer returns a new sync.Interface for reconciling
+// the copy of a blueprint site, which gets deployed in the provisioned namespace.
+func NewProvisionedWordpressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	obj := &wordpressv1alpha1.Wordpress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.Name,
+			Namespace: wp.ProvisionedNamespace(),
+		},
+	}
+
+	return syncer.NewObjectSyncer("ProvisionedWordpress", nil, obj, c, func() error {
+		annotations := labels.Merge(nil, wp.Annotations)
+		delete(annotations, corev1.LastAppliedConfigAnnotation)
+
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, wp.ObjectMeta.Labels), provisionedLabels(wp))
+		obj.Annotations = labels.Merge(obj.Annotations, annotations)
+		obj.Spec = *wp.Spec.DeepCopy()
+
+		return nil
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errKEDAAutoscalingNotDefined = errors.New(".spec.autoscaling.keda is not defined")
+
+// NewScaledObjectSyncer returns a new sync.Interface for reconciling the
+// KEDA ScaledObject of the web deployment.
+func NewScaledObjectSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressScaledObject)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("keda.sh/v1alpha1")
+	obj.SetKind("ScaledObject")
+	obj.SetName(wp.ComponentName(wordpress.WordpressScaledObject))
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("ScaledObject", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels))
+		obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		if !wp.HasKEDAAutoscaling() {
+			return errKEDAAutoscalingNotDefined
+		}
+
+		keda := wp.Spec.Autoscaling.KEDA
+
+		triggers := make([]interface{}, 0, len(keda.Triggers))
+		for _, t := range keda.Triggers {
+			trigger := map[string]interface{}{
+				"type":     t.Type,
+				"metadata": toUnstructuredMap(t.Metadata),
+			}
+
+			if len(t.AuthenticationRef) > 0 {
+				trigger["authenticationRef"] = map[string]interface{}{
+					"name": t.AuthenticationRef,
+				}
+			}
+
+			triggers = append(triggers, trigger)
+		}
+
+		spec := map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{
+				"name": wp.ComponentName(wordpress.WordpressDeployment),
+			},
+			"minReplicaCount": int64(*keda.MinReplicas),
+			"maxReplicaCount": int64(keda.MaxReplicas),
+			"triggers":        triggers,
+		}
+
+		if keda.PollingInterval != nil {
+			spec["pollingInterval"] = int64(*keda.PollingInterval)
+		}
+
+		if keda.CooldownPeriod != nil {
+			spec["cooldownPeriod"] = int64(*keda.CooldownPeriod)
+		}
+
+		return unstructured.SetNestedMap(obj.Object, spec, "spec")
+	})
+}
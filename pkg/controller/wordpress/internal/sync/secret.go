@@ -52,6 +52,7 @@ func NewSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 
 	return syncer.NewObjectSyncer("Secret", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
 
 		if len(obj.Data) == 0 {
 			obj.Data = make(map[string][]byte)
@@ -32,6 +32,13 @@ import (
 
 var errImmutableServiceSelector = errors.New("service selector is immutable")
 
+// topologyAnnotations enable the topology aware routing, the first one being
+// used up to Kubernetes 1.26 and the second one afterwards.
+var topologyAnnotations = map[string]string{
+	"service.kubernetes.io/topology-aware-hints": "auto",
+	"service.kubernetes.io/topology-mode":        "Auto",
+}
+
 // NewServiceSyncer returns a new sync.Interface for reconciling web Service.
 func NewServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
 	objLabels := wp.ComponentLabels(wordpress.WordpressDeployment)
@@ -45,6 +52,11 @@ func NewServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 
 	return syncer.NewObjectSyncer("Service", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+		setTopologyAnnotations(wp, obj)
+		if wp.Spec.Service != nil {
+			obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.Service.Annotations)
+		}
 
 		selector := wp.WebPodLabels()
 		if !labels.Equals(selector, obj.Spec.Selector) {
@@ -55,8 +67,28 @@ func NewServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 			}
 		}
 
-		if len(obj.Spec.Ports) != 2 {
-			obj.Spec.Ports = make([]corev1.ServicePort, 2)
+		setServiceType(wp, obj)
+		setServiceIPFamilies(wp, obj)
+
+		if wp.HasStickySessions() {
+			obj.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+			obj.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+				ClientIP: &corev1.ClientIPConfig{
+					TimeoutSeconds: wp.Spec.Routing.StickySessions.TimeoutSeconds,
+				},
+			}
+		} else {
+			obj.Spec.SessionAffinity = corev1.ServiceAffinityNone
+			obj.Spec.SessionAffinityConfig = nil
+		}
+
+		ports := 2
+		if wp.HasMetrics() {
+			ports = 4
+		}
+
+		if len(obj.Spec.Ports) != ports {
+			obj.Spec.Ports = make([]corev1.ServicePort, ports)
 		}
 
 		obj.Spec.Ports[0].Name = "http"
@@ -67,6 +99,76 @@ func NewServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 		obj.Spec.Ports[1].Port = int32(wordpress.MetricsExporterPort)
 		obj.Spec.Ports[1].TargetPort = intstr.FromInt(wordpress.MetricsExporterPort)
 
+		if wp.HasMetrics() {
+			obj.Spec.Ports[2].Name = wordpress.NginxExporterPortName
+			obj.Spec.Ports[2].Port = int32(wordpress.NginxExporterPort)
+			obj.Spec.Ports[2].TargetPort = intstr.FromInt(wordpress.NginxExporterPort)
+
+			obj.Spec.Ports[3].Name = wordpress.PHPFPMExporterPortName
+			obj.Spec.Ports[3].Port = int32(wordpress.PHPFPMExporterPort)
+			obj.Spec.Ports[3].TargetPort = intstr.FromInt(wordpress.PHPFPMExporterPort)
+		}
+
+		// node ports are not allowed on ClusterIP services, so drop the
+		// allocated ones when switching back
+		if obj.Spec.Type == corev1.ServiceTypeClusterIP {
+			for i := range obj.Spec.Ports {
+				obj.Spec.Ports[i].NodePort = 0
+			}
+		}
+
 		return nil
 	})
 }
+
+func setServiceType(wp *wordpress.Wordpress, obj *corev1.Service) {
+	obj.Spec.Type = wp.ServiceType()
+
+	if obj.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		obj.Spec.LoadBalancerSourceRanges = wp.Spec.Service.LoadBalancerSourceRanges
+	} else {
+		obj.Spec.LoadBalancerSourceRanges = nil
+	}
+
+	switch {
+	case obj.Spec.Type == corev1.ServiceTypeClusterIP:
+		obj.Spec.ExternalTrafficPolicy = ""
+	case wp.Spec.Service.ExternalTrafficPolicy != "":
+		obj.Spec.ExternalTrafficPolicy = wp.Spec.Service.ExternalTrafficPolicy
+	default:
+		obj.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	}
+
+	if obj.Spec.Type != corev1.ServiceTypeLoadBalancer || obj.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+		obj.Spec.HealthCheckNodePort = 0
+	}
+}
+
+// setServiceIPFamilies sets the IP families only when they are configured, as
+// they get defaulted by the API server based on the cluster networking.
+func setServiceIPFamilies(wp *wordpress.Wordpress, obj *corev1.Service) {
+	if wp.Spec.Service == nil {
+		return
+	}
+
+	if wp.Spec.Service.IPFamilyPolicy != nil {
+		policy := *wp.Spec.Service.IPFamilyPolicy
+		obj.Spec.IPFamilyPolicy = &policy
+	}
+
+	if len(wp.Spec.Service.IPFamilies) > 0 {
+		obj.Spec.IPFamilies = append([]corev1.IPFamily{}, wp.Spec.Service.IPFamilies...)
+	}
+}
+
+func setTopologyAnnotations(wp *wordpress.Wordpress, obj *corev1.Service) {
+	if wp.Spec.Service != nil && wp.Spec.Service.TopologyAwareRouting {
+		obj.Annotations = labels.Merge(obj.Annotations, topologyAnnotations)
+
+		return
+	}
+
+	for k := range topologyAnnotations {
+		delete(obj.Annotations, k)
+	}
+}
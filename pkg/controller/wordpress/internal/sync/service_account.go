@@ -0,0 +1,68 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	eksRoleARNAnnotationKey        = "eks.amazonaws.com/role-arn"
+	gkeServiceAccountAnnotationKey = "iam.gke.io/gcp-service-account"
+)
+
+// NewServiceAccountSyncer returns a new sync.Interface for reconciling the
+// site ServiceAccount, used for accessing the media bucket through workload identity.
+func NewServiceAccountSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressServiceAccount)
+
+	obj := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressServiceAccount),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("ServiceAccount", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		if len(obj.Annotations) == 0 {
+			obj.Annotations = make(map[string]string)
+		}
+
+		delete(obj.Annotations, eksRoleARNAnnotationKey)
+		delete(obj.Annotations, gkeServiceAccountAnnotationKey)
+
+		if s3 := wp.Spec.MediaVolumeSpec.S3VolumeSource; s3 != nil && len(s3.IAMRole) > 0 {
+			obj.Annotations[eksRoleARNAnnotationKey] = s3.IAMRole
+		}
+
+		if gcs := wp.Spec.MediaVolumeSpec.GCSVolumeSource; gcs != nil && len(gcs.ServiceAccount) > 0 {
+			obj.Annotations[gkeServiceAccountAnnotationKey] = gcs.ServiceAccount
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errServiceMonitorNotDefined = errors.New(".spec.metrics.serviceMonitor is not defined")
+
+// NewServiceMonitorSyncer returns a new sync.Interface for reconciling the
+// Prometheus Operator ServiceMonitor scraping the site metrics.
+func NewServiceMonitorSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressServiceMonitor)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("monitoring.coreos.com/v1")
+	obj.SetKind("ServiceMonitor")
+	obj.SetName(wp.ComponentName(wordpress.WordpressServiceMonitor))
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("ServiceMonitor", wp.Unwrap(), obj, c, func() error {
+		if !wp.HasServiceMonitor() {
+			return errServiceMonitorNotDefined
+		}
+
+		monitor := wp.Spec.Metrics.ServiceMonitor
+
+		obj.SetLabels(labels.Merge(labels.Merge(labels.Merge(obj.GetLabels(), monitor.Labels), objLabels), controllerLabels))
+		obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		endpoints := []interface{}{}
+		for _, port := range []string{"prometheus", wordpress.NginxExporterPortName, wordpress.PHPFPMExporterPortName} {
+			endpoints = append(endpoints, map[string]interface{}{
+				"port":     port,
+				"interval": monitor.Interval.Duration.String(),
+			})
+		}
+
+		spec := map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": toUnstructuredMap(wp.WebPodLabels()),
+			},
+			"endpoints": endpoints,
+		}
+
+		return unstructured.SetNestedMap(obj.Object, spec, "spec")
+	})
+}
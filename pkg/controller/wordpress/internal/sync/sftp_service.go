@@ -0,0 +1,69 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewSFTPServiceSyncer returns a new sync.Interface for reconciling the Service
+// exposing the SFTP sidecars.
+func NewSFTPServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressSFTPService)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressSFTPService),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("SFTPService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		selector := wp.WebPodLabels()
+		if !labels.Equals(selector, obj.Spec.Selector) {
+			if obj.ObjectMeta.CreationTimestamp.IsZero() {
+				obj.Spec.Selector = selector
+			} else {
+				return errImmutableServiceSelector
+			}
+		}
+
+		obj.Spec.Type = wp.Spec.DeveloperAccess.SFTP.ServiceType
+
+		if len(obj.Spec.Ports) != 1 {
+			obj.Spec.Ports = make([]corev1.ServicePort, 1)
+		}
+
+		obj.Spec.Ports[0].Name = wordpress.SFTPPortName
+		obj.Spec.Ports[0].Port = int32(wordpress.SFTPPort)
+		obj.Spec.Ports[0].TargetPort = intstr.FromString(wordpress.SFTPPortName)
+		obj.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+
+		return nil
+	})
+}
@@ -41,20 +41,18 @@ func NewDBUpgradeJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Inte
 		},
 	}
 
-	var (
-		backoffLimit          int32
-		activeDeadlineSeconds int64 = 10
-	)
+	var activeDeadlineSeconds int64 = 10
 
 	return syncer.NewObjectSyncer("DBUpgradeJob", wp.Unwrap(), obj, c, func() error {
 		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
 
 		if !obj.CreationTimestamp.IsZero() {
 			// TODO (calind): handle the case that the existing job is failed
 			return nil
 		}
 
-		obj.Spec.BackoffLimit = &backoffLimit
+		setJobLimits(&obj.Spec)
 		obj.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
 
 		cmd := []string{"/bin/sh", "-c", "wp core update-db --network || wp core update-db && wp cache flush"}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errVerticalAutoscalingNotDefined = errors.New(".spec.autoscaling.vertical is not defined")
+
+// NewVPASyncer returns a new sync.Interface for reconciling the
+// VerticalPodAutoscaler of the web deployment.
+func NewVPASyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressVPA)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("autoscaling.k8s.io/v1")
+	obj.SetKind("VerticalPodAutoscaler")
+	obj.SetName(wp.ComponentName(wordpress.WordpressVPA))
+	obj.SetNamespace(wp.Namespace)
+
+	return syncer.NewObjectSyncer("VerticalPodAutoscaler", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels))
+		obj.SetAnnotations(labels.Merge(obj.GetAnnotations(), wp.Spec.CommonAnnotations))
+
+		if !wp.HasVerticalAutoscaling() {
+			return errVerticalAutoscalingNotDefined
+		}
+
+		vertical := wp.Spec.Autoscaling.Vertical
+
+		containerPolicy := map[string]interface{}{
+			"containerName": "wordpress",
+		}
+
+		if len(vertical.MinAllowed) > 0 {
+			containerPolicy["minAllowed"] = resourceListToUnstructured(vertical.MinAllowed)
+		}
+
+		if len(vertical.MaxAllowed) > 0 {
+			containerPolicy["maxAllowed"] = resourceListToUnstructured(vertical.MaxAllowed)
+		}
+
+		spec := map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"name":       wp.ComponentName(wordpress.WordpressDeployment),
+			},
+			"updatePolicy": map[string]interface{}{
+				"updateMode": vertical.UpdateMode,
+			},
+			"resourcePolicy": map[string]interface{}{
+				"containerPolicies": []interface{}{containerPolicy},
+			},
+		}
+
+		return unstructured.SetNestedMap(obj.Object, spec, "spec")
+	})
+}
+
+func resourceListToUnstructured(in corev1.ResourceList) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for name, quantity := range in {
+		out[string(name)] = quantity.String()
+	}
+
+	return out
+}
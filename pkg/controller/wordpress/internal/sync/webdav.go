@@ -0,0 +1,120 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewWebDAVSyncers returns the sync.Interfaces for reconciling the Service
+// and the Ingress exposing the WebDAV sidecars.
+func NewWebDAVSyncers(wp *wordpress.Wordpress, c client.Client) []syncer.Interface {
+	return []syncer.Interface{
+		newWebDAVServiceSyncer(wp, c),
+		newWebDAVIngressSyncer(wp, c),
+	}
+}
+
+func newWebDAVServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressWebDAV)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressWebDAV),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("WebDAVService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		selector := wp.WebPodLabels()
+		if !labels.Equals(selector, obj.Spec.Selector) {
+			if obj.ObjectMeta.CreationTimestamp.IsZero() {
+				obj.Spec.Selector = selector
+			} else {
+				return errImmutableServiceSelector
+			}
+		}
+
+		if len(obj.Spec.Ports) != 1 {
+			obj.Spec.Ports = make([]corev1.ServicePort, 1)
+		}
+
+		obj.Spec.Ports[0].Name = "http"
+		obj.Spec.Ports[0].Port = int32(80)
+		obj.Spec.Ports[0].TargetPort = intstr.FromString(wordpress.WebDAVPortName)
+
+		return nil
+	})
+}
+
+func newWebDAVIngressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressWebDAV)
+
+	obj := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressWebDAV),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	bk := netv1.IngressBackend{
+		Service: &netv1.IngressServiceBackend{
+			Name: wp.ComponentName(wordpress.WordpressWebDAV),
+			Port: netv1.ServiceBackendPort{Name: "http"},
+		},
+	}
+
+	return syncer.NewObjectSyncer("WebDAVIngress", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Annotations = labels.Merge(obj.Annotations, wp.Spec.CommonAnnotations)
+
+		if options.IngressClass != "" {
+			obj.Spec.IngressClassName = &options.IngressClass
+		} else {
+			obj.Spec.IngressClassName = nil
+		}
+
+		webdav := wp.Spec.DeveloperAccess.WebDAV
+		obj.Spec.Rules = upsertPath(nil, webdav.Domain, "/", bk)
+
+		if len(webdav.TLSSecretRef) > 0 {
+			obj.Spec.TLS = []netv1.IngressTLS{
+				{
+					SecretName: webdav.TLSSecretRef,
+					Hosts:      []string{webdav.Domain},
+				},
+			}
+		} else {
+			obj.Spec.TLS = nil
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// updateLoadBalancerStatus publishes the address of the load balancer exposing
+// the site, emitting an event when it changes.
+func (r *ReconcileWordpress) updateLoadBalancerStatus(wp *wordpress.Wordpress, status corev1.LoadBalancerStatus) {
+	var lb *corev1.LoadBalancerStatus
+	if len(status.Ingress) > 0 {
+		lb = status.DeepCopy()
+	}
+
+	if equality.Semantic.DeepEqual(lb, wp.Status.LoadBalancer) {
+		return
+	}
+
+	wp.Status.LoadBalancer = lb
+
+	if lb != nil {
+		r.recorder.Eventf(wp.Unwrap(), corev1.EventTypeNormal, "LoadBalancerUpdated",
+			"The site is exposed through %s", loadBalancerAddresses(lb))
+	}
+}
+
+func loadBalancerAddresses(lb *corev1.LoadBalancerStatus) string {
+	addresses := make([]string, 0, len(lb.Ingress))
+
+	for _, ingress := range lb.Ingress {
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		} else {
+			addresses = append(addresses, ingress.IP)
+		}
+	}
+
+	return strings.Join(addresses, ", ")
+}
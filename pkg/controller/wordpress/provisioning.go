@@ -0,0 +1,122 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+
+	"github.com/presslabs/controller-util/syncer"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const provisionedNamespaceFinalizer = "wordpress.presslabs.org/provisioned-namespace"
+
+// provisionNamespace provisions a dedicated namespace for a site created in
+// the blueprint namespace, copies into it the ResourceQuotas, LimitRanges and
+// NetworkPolicies of the blueprint namespace and creates the site inside it.
+func (r *ReconcileWordpress) provisionNamespace(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.DeletionTimestamp.IsZero() {
+		return r.deprovisionNamespace(ctx, wp)
+	}
+
+	if !controllerutil.ContainsFinalizer(wp.Unwrap(), provisionedNamespaceFinalizer) {
+		controllerutil.AddFinalizer(wp.Unwrap(), provisionedNamespaceFinalizer)
+
+		if err := r.Update(ctx, wp.Unwrap()); err != nil {
+			return err
+		}
+	}
+
+	syncers := []syncer.Interface{
+		sync.NewProvisionedNamespaceSyncer(wp, r.Client),
+	}
+
+	quotas := &corev1.ResourceQuotaList{}
+	if err := r.List(ctx, quotas, client.InNamespace(wp.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range quotas.Items {
+		syncers = append(syncers, sync.NewProvisionedResourceQuotaSyncer(wp, &quotas.Items[i], r.Client))
+	}
+
+	limitRanges := &corev1.LimitRangeList{}
+	if err := r.List(ctx, limitRanges, client.InNamespace(wp.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range limitRanges.Items {
+		syncers = append(syncers, sync.NewProvisionedLimitRangeSyncer(wp, &limitRanges.Items[i], r.Client))
+	}
+
+	policies := &netv1.NetworkPolicyList{}
+	if err := r.List(ctx, policies, client.InNamespace(wp.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range policies.Items {
+		syncers = append(syncers, sync.NewProvisionedNetworkPolicySyncer(wp, &policies.Items[i], r.Client))
+	}
+
+	syncers = append(syncers, sync.NewProvisionedWordpressSyncer(wp, r.Client))
+
+	if err := r.sync(ctx, wp, syncers); err != nil {
+		return err
+	}
+
+	if wp.Status.ProvisionedNamespace == wp.ProvisionedNamespace() && wp.Status.ObservedGeneration == wp.Generation {
+		return nil
+	}
+
+	wp.Status.ProvisionedNamespace = wp.ProvisionedNamespace()
+	wp.Status.ObservedGeneration = wp.Generation
+
+	return r.Status().Update(ctx, wp.Unwrap())
+}
+
+// deprovisionNamespace deletes the namespace provisioned for a blueprint site,
+// along with the site inside it, once the blueprint site gets deleted.
+func (r *ReconcileWordpress) deprovisionNamespace(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !controllerutil.ContainsFinalizer(wp.Unwrap(), provisionedNamespaceFinalizer) {
+		return nil
+	}
+
+	ns := &corev1.Namespace{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: wp.ProvisionedNamespace()}, ns)
+	if ignoreNotFound(err) != nil {
+		return err
+	}
+
+	// never delete namespaces which weren't provisioned for the site
+	if err == nil && ns.Labels[wordpress.BlueprintLabel] == wp.Name {
+		if err = r.Delete(ctx, ns); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(wp.Unwrap(), provisionedNamespaceFinalizer)
+
+	return r.Update(ctx, wp.Unwrap())
+}
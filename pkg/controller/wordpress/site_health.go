@@ -0,0 +1,207 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	siteHealthTimeout = 5 * time.Second
+
+	siteHealthUsernameKey = "username"
+	siteHealthPasswordKey = "password"
+
+	siteHealthGood     = "good"
+	siteHealthCritical = "critical"
+)
+
+var (
+	errSiteHealthCredentials = errors.New("missing Site Health credentials")
+	errSiteHealthStatus      = errors.New("unexpected Site Health response status")
+)
+
+// siteHealthClient is used for requesting the Site Health tests. The tests
+// run inside Reconcile, so an unreachable site must not block the worker for
+// long.
+var siteHealthClient = &http.Client{Timeout: siteHealthTimeout}
+
+// siteHealthHost returns the address the Site Health tests are requested
+// from. The site is reached through its Service, so the tests don't depend
+// on the ingress.
+var siteHealthHost = func(wp *wordpress.Wordpress) string {
+	return fmt.Sprintf("%s.%s.svc", wp.ComponentName(wordpress.WordpressService), wp.Namespace)
+}
+
+type siteHealthResult struct {
+	Label  string `json:"label"`
+	Status string `json:"status"`
+}
+
+// checkSiteHealth runs the Site Health REST API tests every
+// spec.siteHealth.interval and summarizes them into the status. The returned
+// duration is the time after which the site should be reconciled again.
+func (r *ReconcileWordpress) checkSiteHealth(ctx context.Context, wp *wordpress.Wordpress) (time.Duration, error) {
+	if wp.Spec.SiteHealth == nil {
+		wp.Status.SiteHealth = nil
+
+		return 0, nil
+	}
+
+	now := time.Now()
+	interval := wp.Spec.SiteHealth.Interval.Duration
+	status := wp.Status.SiteHealth
+
+	if status != nil && status.LastCheckTime != nil && now.Before(status.LastCheckTime.Add(interval)) {
+		return status.LastCheckTime.Add(interval).Sub(now), nil
+	}
+
+	// the check time is recorded even if the tests can't be run, so that
+	// unrelated reconciliations don't retry them before the next interval
+	checkTime := metav1.NewTime(now)
+	if status == nil {
+		status = &wordpressv1alpha1.SiteHealthStatus{}
+	}
+	status.LastCheckTime = &checkTime
+	wp.Status.SiteHealth = status
+
+	username, password, err := r.siteHealthCredentials(ctx, wp)
+	if errors.Is(err, errSiteHealthCredentials) || apierrors.IsNotFound(err) {
+		wp.SetCondition(wordpressv1alpha1.SiteHealthyCondition, corev1.ConditionUnknown,
+			wordpressv1alpha1.SiteHealthCredentialsMissingReason, err.Error())
+
+		return interval, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	result := &wordpressv1alpha1.SiteHealthStatus{LastCheckTime: &checkTime}
+
+	for _, test := range wp.Spec.SiteHealth.Tests {
+		testResult, testErr := runSiteHealthTest(ctx, wp, test, username, password)
+		if testErr != nil {
+			wp.SetCondition(wordpressv1alpha1.SiteHealthyCondition, corev1.ConditionUnknown,
+				wordpressv1alpha1.SiteHealthCheckFailedReason, fmt.Sprintf("failed to run the %s test: %s", test, testErr))
+
+			return interval, nil
+		}
+
+		if testResult.Status == siteHealthGood {
+			continue
+		}
+
+		if testResult.Status == siteHealthCritical {
+			result.Critical++
+		} else {
+			result.Recommended++
+		}
+
+		result.Issues = append(result.Issues, wordpressv1alpha1.SiteHealthIssue{
+			Test:   test,
+			Status: testResult.Status,
+			Label:  testResult.Label,
+		})
+	}
+
+	wp.Status.SiteHealth = result
+
+	if result.Critical > 0 {
+		wp.SetCondition(wordpressv1alpha1.SiteHealthyCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.SiteHealthCriticalIssuesReason, fmt.Sprintf("%d critical issues found", result.Critical))
+	} else {
+		wp.SetCondition(wordpressv1alpha1.SiteHealthyCondition, corev1.ConditionTrue,
+			wordpressv1alpha1.SiteHealthyReason, "no critical issues found")
+	}
+
+	return interval, nil
+}
+
+// siteHealthCredentials returns the application password used for
+// authenticating the Site Health API requests.
+func (r *ReconcileWordpress) siteHealthCredentials(ctx context.Context, wp *wordpress.Wordpress) (string, string, error) {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{
+		Name:      wp.Spec.SiteHealth.ApplicationPasswordSecretRef.Name,
+		Namespace: wp.Namespace,
+	}
+
+	if err := r.referencesReader().Get(ctx, key, secret); err != nil {
+		return "", "", err
+	}
+
+	username := string(secret.Data[siteHealthUsernameKey])
+	password := string(secret.Data[siteHealthPasswordKey])
+
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("%w: secret %s should define the %s and %s keys",
+			errSiteHealthCredentials, key.Name, siteHealthUsernameKey, siteHealthPasswordKey)
+	}
+
+	return username, password, nil
+}
+
+func runSiteHealthTest(ctx context.Context, wp *wordpress.Wordpress, test, username, password string) (*siteHealthResult, error) {
+	u, err := url.Parse(wp.HomeURL("wp-json/wp-site-health/v1/tests", test))
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = "http"
+	u.Host = siteHealthHost(wp)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Host = wp.MainDomain()
+	req.SetBasicAuth(username, password)
+
+	resp, err := siteHealthClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errSiteHealthStatus, resp.Status)
+	}
+
+	result := &siteHealthResult{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
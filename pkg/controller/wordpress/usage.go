@@ -0,0 +1,292 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const milliPerUnit = 1000
+
+// meteredResources are the compute resources reported through the usage metrics.
+var meteredResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage}
+
+// siteVolumes are the names of the site volumes which may be backed by claims.
+var siteVolumes = []string{"code", "media"}
+
+var (
+	siteReplicasGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_site_replicas",
+		Help: "The number of web pods of the site.",
+	}, []string{"namespace", "wordpress"})
+
+	siteResourceRequestsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_site_resource_requests",
+		Help: "The compute resources requested by all the web pods of the site, in cores for cpu and bytes otherwise.",
+	}, []string{"namespace", "wordpress", "resource"})
+
+	siteVolumeCapacityGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_site_volume_capacity_bytes",
+		Help: "The provisioned capacity of the site volume claims.",
+	}, []string{"namespace", "wordpress", "volume"})
+
+	siteVolumeUsedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_site_volume_used_bytes",
+		Help: "The space used on the site volumes, as reported by the kubelet.",
+	}, []string{"namespace", "wordpress", "volume"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(siteReplicasGauge, siteResourceRequestsGauge, siteVolumeCapacityGauge, siteVolumeUsedGauge)
+}
+
+// kubeletStatsSummary is the subset of the kubelet stats summary holding the
+// pod volumes usage.
+type kubeletStatsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Volumes []struct {
+			UsedBytes *uint64 `json:"usedBytes"`
+			PVCRef    *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// updateUsage summarizes the resources used by the site into the status and
+// the usage metrics. The volumes usage is collected from the kubelets every
+// --volume-stats-interval, if enabled. The returned duration is the time
+// after which the site should be reconciled again.
+func (r *ReconcileWordpress) updateUsage(ctx context.Context, wp *wordpress.Wordpress, deploy *appsv1.Deployment) (time.Duration, error) {
+	old := wp.Status.Usage
+	usage := &wordpressv1alpha1.UsageStatus{}
+
+	if deploy != nil {
+		usage.Replicas = deploy.Status.Replicas
+		usage.Requests = podRequests(&deploy.Spec.Template.Spec, usage.Replicas)
+	}
+
+	claims := wp.VolumeClaims()
+
+	for _, name := range siteVolumes {
+		claimName, found := claims[name]
+		if !found {
+			continue
+		}
+
+		volume := wordpressv1alpha1.VolumeUsage{Name: name, ClaimName: claimName}
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := r.referencesReader().Get(ctx, types.NamespacedName{Name: claimName, Namespace: wp.Namespace}, pvc); ignoreNotFound(err) != nil {
+			return 0, err
+		}
+
+		if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			volume.Capacity = &capacity
+		}
+
+		// keep the previously collected usage until it gets refreshed
+		if prev := volumeUsage(old, name); prev != nil && prev.ClaimName == claimName {
+			volume.Used = prev.Used
+		}
+
+		usage.Volumes = append(usage.Volumes, volume)
+	}
+
+	if old != nil && len(usage.Volumes) > 0 {
+		usage.VolumeStatsTime = old.VolumeStatsTime
+	}
+
+	after, err := r.collectVolumeStats(ctx, wp, usage)
+	if err != nil {
+		return 0, err
+	}
+
+	wp.Status.Usage = usage
+	setUsageMetrics(wp, usage)
+
+	return after, nil
+}
+
+// collectVolumeStats fills in the volumes usage from the stats summary of the
+// kubelets running the web pods.
+func (r *ReconcileWordpress) collectVolumeStats(ctx context.Context, wp *wordpress.Wordpress, usage *wordpressv1alpha1.UsageStatus) (time.Duration, error) {
+	if !options.VolumeStatsEnabled || len(usage.Volumes) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	interval := options.VolumeStatsInterval
+
+	if usage.VolumeStatsTime != nil && now.Before(usage.VolumeStatsTime.Add(interval)) {
+		return usage.VolumeStatsTime.Add(interval).Sub(now), nil
+	}
+
+	// the pods are listed from the API server, so they don't get cached
+	pods := &corev1.PodList{}
+	if err := r.apiReader.List(ctx, pods, client.InNamespace(wp.Namespace), client.MatchingLabels(wp.WebPodLabels())); err != nil {
+		return 0, err
+	}
+
+	nodes := map[string]bool{}
+	for i := range pods.Items {
+		if node := pods.Items[i].Spec.NodeName; node != "" {
+			nodes[node] = true
+		}
+	}
+
+	used := map[string]uint64{}
+
+	for node := range nodes {
+		summary, err := r.nodeStatsSummary(ctx, node)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, pod := range summary.Pods {
+			if pod.PodRef.Namespace != wp.Namespace {
+				continue
+			}
+
+			for _, volume := range pod.Volumes {
+				if volume.PVCRef != nil && volume.UsedBytes != nil {
+					used[volume.PVCRef.Name] = *volume.UsedBytes
+				}
+			}
+		}
+	}
+
+	for i := range usage.Volumes {
+		if bytes, found := used[usage.Volumes[i].ClaimName]; found {
+			usage.Volumes[i].Used = resource.NewQuantity(int64(bytes), resource.BinarySI)
+		}
+	}
+
+	collectTime := metav1.NewTime(now)
+	usage.VolumeStatsTime = &collectTime
+
+	return interval, nil
+}
+
+func (r *ReconcileWordpress) nodeStatsSummary(ctx context.Context, node string) (*kubeletStatsSummary, error) {
+	data, err := r.kubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(node).SubResource("proxy").Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &kubeletStatsSummary{}
+	if err = json.Unmarshal(data, summary); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// podRequests returns the compute resources requested by the given number of
+// pods, including their sidecars.
+func podRequests(spec *corev1.PodSpec, replicas int32) corev1.ResourceList {
+	total := corev1.ResourceList{}
+
+	for i := range spec.Containers {
+		for name, quantity := range spec.Containers[i].Resources.Requests {
+			sum := total[name]
+			sum.Add(quantity)
+			total[name] = sum
+		}
+	}
+
+	for name, quantity := range total {
+		total[name] = *resource.NewMilliQuantity(quantity.MilliValue()*int64(replicas), quantity.Format)
+	}
+
+	return total
+}
+
+func volumeUsage(usage *wordpressv1alpha1.UsageStatus, name string) *wordpressv1alpha1.VolumeUsage {
+	if usage == nil {
+		return nil
+	}
+
+	for i := range usage.Volumes {
+		if usage.Volumes[i].Name == name {
+			return &usage.Volumes[i]
+		}
+	}
+
+	return nil
+}
+
+func setUsageMetrics(wp *wordpress.Wordpress, usage *wordpressv1alpha1.UsageStatus) {
+	deleteUsageMetrics(wp.Namespace, wp.Name)
+
+	siteReplicasGauge.WithLabelValues(wp.Namespace, wp.Name).Set(float64(usage.Replicas))
+
+	for _, name := range meteredResources {
+		if quantity, found := usage.Requests[name]; found {
+			siteResourceRequestsGauge.WithLabelValues(wp.Namespace, wp.Name, string(name)).
+				Set(float64(quantity.MilliValue()) / milliPerUnit)
+		}
+	}
+
+	for i := range usage.Volumes {
+		volume := &usage.Volumes[i]
+
+		if volume.Capacity != nil {
+			siteVolumeCapacityGauge.WithLabelValues(wp.Namespace, wp.Name, volume.Name).Set(float64(volume.Capacity.Value()))
+		}
+
+		if volume.Used != nil {
+			siteVolumeUsedGauge.WithLabelValues(wp.Namespace, wp.Name, volume.Name).Set(float64(volume.Used.Value()))
+		}
+	}
+}
+
+// deleteUsageMetrics removes the usage metrics of a site, e.g. once it gets deleted.
+func deleteUsageMetrics(namespace, name string) {
+	siteReplicasGauge.DeleteLabelValues(namespace, name)
+
+	for _, resourceName := range meteredResources {
+		siteResourceRequestsGauge.DeleteLabelValues(namespace, name, string(resourceName))
+	}
+
+	for _, volume := range siteVolumes {
+		siteVolumeCapacityGauge.DeleteLabelValues(namespace, name, volume)
+		siteVolumeUsedGauge.DeleteLabelValues(namespace, name, volume)
+	}
+}
@@ -18,31 +18,51 @@ package wordpress
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/presslabs/controller-util/syncer"
+	"golang.org/x/time/rate"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
 	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
 	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
 )
 
 const controllerName = "wordpress-controller"
 
+const (
+	// retryQPS and retryBurst limit the overall rate of the reconciliations
+	// retries, as in the default controller rate limiter.
+	retryQPS   = 10
+	retryBurst = 100
+)
+
 // Add creates a new Wordpress Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
@@ -51,28 +71,50 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler.
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileWordpress{Client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(controllerName)}
+	return &ReconcileWordpress{
+		Client:     mgr.GetClient(),
+		apiReader:  mgr.GetAPIReader(),
+		kubeClient: kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+		scheme:     mgr.GetScheme(),
+		recorder:   mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// rateLimiter returns the rate limiter of the reconcile queue. As the default
+// controller rate limiter, it limits the overall retries rate too, so a
+// fleet-wide transient failure doesn't make all the sites retry at once.
+func rateLimiter() workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(options.RetryBaseDelay, options.RetryMaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(retryQPS), retryBurst)},
+	)
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{
+		Reconciler:  r,
+		RateLimiter: rateLimiter(),
+	})
 	if err != nil {
 		return err
 	}
 
 	// Watch for changes to Wordpress
-	err = c.Watch(&source.Kind{Type: &wordpressv1alpha1.Wordpress{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &wordpressv1alpha1.Wordpress{}}, &handler.EnqueueRequestForObject{}, inShard)
 	if err != nil {
 		return err
 	}
 
 	subresources := []client.Object{
 		&appsv1.Deployment{},
+		&batchv1.Job{},
+		&corev1.ConfigMap{},
 		&corev1.PersistentVolumeClaim{},
 		&corev1.Service{},
 		&corev1.Secret{},
+		&corev1.ServiceAccount{},
 		&netv1.Ingress{},
 	}
 
@@ -86,23 +128,81 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		}
 	}
 
+	// Watch for the HorizontalPodAutoscalers targeting the sites deployments
+	err = c.Watch(&source.Kind{Type: &autoscalingv1.HorizontalPodAutoscaler{}}, handler.EnqueueRequestsFromMapFunc(hpaToWordpress))
+	if err != nil {
+		return err
+	}
+
+	// Watch for the database secrets materialized by External Secrets Operator
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(databaseSecretToWordpress))
+	if err != nil {
+		return err
+	}
+
+	// Watch for the Secrets and ConfigMaps referenced through envFrom
+	sources, err := envFromSources(mgr)
+	if err != nil {
+		return err
+	}
+
+	for kind, src := range sources {
+		err = c.Watch(src, handler.EnqueueRequestsFromMapFunc(envFromToWordpress(mgr.GetClient(), kind)))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// inShard filters out the events of the sites reconciled by the other operator replicas.
+var inShard = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	wp, ok := obj.(*wordpressv1alpha1.Wordpress)
+
+	return ok && wordpress.New(wp).InShard()
+})
+
+func databaseSecretToWordpress(obj client.Object) []reconcile.Request {
+	l := obj.GetLabels()
+	if l["app.kubernetes.io/component"] != "database" || l["app.kubernetes.io/instance"] == "" {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Name:      l["app.kubernetes.io/instance"],
+				Namespace: obj.GetNamespace(),
+			},
+		},
+	}
+}
+
 var _ reconcile.Reconciler = &ReconcileWordpress{}
 
 // ReconcileWordpress reconciles a Wordpress object.
 type ReconcileWordpress struct {
 	client.Client
-	scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	apiReader  client.Reader
+	kubeClient kubernetes.Interface
+	scheme     *runtime.Scheme
+	recorder   record.EventRecorder
 }
 
 // Automatically generate RBAC rules to allow the Controller to read and write Deployments
-// +kubebuilder:rbac:groups=core,resources=secrets;services;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets;configmaps;services;serviceaccounts;persistentvolumeclaims;pods;events;namespaces;resourcequotas;limitranges,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses;networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;prometheusrules;probes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=external-secrets.io,resources=externalsecrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=s3.aws.crossplane.io,resources=buckets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=storage.cnrm.cloud.google.com,resources=storagebuckets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=iam.cnrm.cloud.google.com,resources=iampolicymembers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpresses;wordpresses/status,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile reads that state of the cluster for a Wordpress object and makes changes based on the state read
@@ -112,8 +212,24 @@ func (r *ReconcileWordpress) Reconcile(ctx context.Context, request reconcile.Re
 	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
 
 	err := r.Get(ctx, request.NamespacedName, wp.Unwrap())
+	if errors.IsNotFound(err) {
+		// the site got deleted
+		deleteUsageMetrics(request.Namespace, request.Name)
+
+		return reconcile.Result{}, nil
+	}
+
 	if err != nil {
-		return reconcile.Result{}, ignoreNotFound(err)
+		return reconcile.Result{}, err
+	}
+
+	// the sites of the other shards are reconciled by the other operator replicas
+	if !wp.InShard() {
+		return reconcile.Result{}, nil
+	}
+
+	if wp.IsBlueprint() {
+		return reconcile.Result{}, r.provisionNamespace(ctx, wp)
 	}
 
 	if updated, needsMigration := r.maybeMigrate(wp.Unwrap()); needsMigration {
@@ -125,17 +241,57 @@ func (r *ReconcileWordpress) Reconcile(ctx context.Context, request reconcile.Re
 	r.scheme.Default(wp.Unwrap())
 	wp.SetDefaults()
 
+	oldStatus := wp.Status.DeepCopy()
+
+	imageCheckAfter, err := r.pinImageDigest(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	dbSecret, dbSecretReady, err := r.databaseSecret(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	envFromChecksum, err := r.envFromChecksum(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	autoscaled, err := r.isAutoscaled(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// the HPA created by KEDA may not exist yet
+	autoscaled = autoscaled || wp.HasKEDAAutoscaling()
+
 	secretSyncer := sync.NewSecretSyncer(wp, r.Client)
-	deploySyncer := sync.NewDeploymentSyncer(wp, secretSyncer.Object().(*corev1.Secret), r.Client)
+	deploySyncer := sync.NewDeploymentSyncer(wp, secretSyncer.Object().(*corev1.Secret), dbSecret, envFromChecksum, autoscaled, r.Client)
 	syncers := []syncer.Interface{
 		secretSyncer,
-		deploySyncer,
-		sync.NewServiceSyncer(wp, r.Client),
-		sync.NewIngressSyncer(wp, r.Client),
+	}
+
+	// the deployment is not rolled out until the database credentials get materialized
+	if dbSecretReady {
+		syncers = append(syncers, deploySyncer)
+	}
+
+	serviceSyncer := sync.NewServiceSyncer(wp, r.Client)
+	syncers = append(syncers,
+		serviceSyncer,
 		// sync.NewDBUpgradeJobSyncer(wp, r.Client),
+	)
+
+	// internal sites are reachable only through the service, so the ingress
+	// is not created and gets garbage collected if it was before
+	var ingressSyncer syncer.Interface
+	if !wp.IsInternal() {
+		ingressSyncer = sync.NewIngressSyncer(wp, r.Client)
+		syncers = append(syncers, ingressSyncer)
 	}
 
-	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil {
+	if wp.HasManagedCodePVC() {
 		syncers = append(syncers, sync.NewCodePVCSyncer(wp, r.Client))
 	}
 
@@ -143,25 +299,277 @@ func (r *ReconcileWordpress) Reconcile(ctx context.Context, request reconcile.Re
 		syncers = append(syncers, sync.NewMediaPVCSyncer(wp, r.Client))
 	}
 
-	if err = r.sync(ctx, syncers); err != nil {
-		return reconcile.Result{}, err
+	if wp.HasPHPConfig() {
+		syncers = append(syncers, sync.NewPHPConfigSyncer(wp, r.Client))
 	}
 
-	oldStatus := wp.Status.DeepCopy()
-	wp.Status.Replicas = deploySyncer.Object().(*appsv1.Deployment).Status.Replicas
+	if wp.HasReadReplicas() {
+		syncers = append(syncers, sync.NewDBConfigSyncer(wp, r.Client))
+	}
+
+	if wp.HasObjectCache() {
+		syncers = append(syncers, sync.NewCacheDropinsSyncer(wp, r.Client))
+	}
+
+	if wp.HasNginxConfig() {
+		syncers = append(syncers, sync.NewNginxConfigSyncer(wp, r.Client))
+	}
+
+	if wp.HasLogShippingSidecar() {
+		syncers = append(syncers, sync.NewLoggingConfigSyncer(wp, r.Client))
+	}
 
-	if oldStatus.Replicas != wp.Status.Replicas {
+	if wp.HasExternalDatabaseSecret() {
+		syncers = append(syncers, sync.NewExternalSecretSyncer(wp, r.Client))
+	}
+
+	if wp.UsesWorkloadIdentity() {
+		syncers = append(syncers, sync.NewServiceAccountSyncer(wp, r.Client))
+	}
+
+	if wp.ShouldProvisionBucket() {
+		syncers = append(syncers, sync.NewMediaBucketSyncers(wp, r.Client)...)
+	}
+
+	if wp.HasServiceMonitor() {
+		syncers = append(syncers, sync.NewServiceMonitorSyncer(wp, r.Client))
+	}
+
+	if wp.HasGrafanaDashboard() {
+		syncers = append(syncers, sync.NewGrafanaDashboardSyncer(wp, r.Client))
+	}
+
+	if wp.HasAlerts() {
+		syncers = append(syncers, sync.NewPrometheusRuleSyncer(wp, r.Client))
+	}
+
+	if wp.HasProbe() {
+		syncers = append(syncers, sync.NewProbeSyncer(wp, r.Client))
+	}
+
+	if wp.HasMediaGC() {
+		syncers = append(syncers, sync.NewMediaGCCronJobSyncer(wp, r.Client))
+	}
+
+	if wp.HasDBMaintenance() {
+		syncers = append(syncers, sync.NewDBMaintenanceCronJobSyncer(wp, r.Client))
+	}
+
+	if wp.HasSFTP() {
+		syncers = append(syncers, sync.NewSFTPServiceSyncer(wp, r.Client))
+	}
+
+	if wp.HasWebDAV() {
+		syncers = append(syncers, sync.NewWebDAVSyncers(wp, r.Client)...)
+	}
+
+	if wp.HasDebugShell() {
+		syncers = append(syncers, sync.NewDebugShellPodSyncer(wp, r.Client))
+	}
+
+	if wp.HasKEDAAutoscaling() {
+		syncers = append(syncers, sync.NewScaledObjectSyncer(wp, r.Client))
+	}
+
+	// the password pending rotation is kept out of the site secret, so the
+	// web pods roll only once it gets promoted
+	var dbCredentialsSyncer syncer.Interface
+	if wp.PendingDBCredentialsRotation() != "" && !wp.HasExternalDatabaseSecret() {
+		dbCredentialsSyncer = sync.NewDBCredentialsSecretSyncer(wp, r.Client)
+		syncers = append(syncers, dbCredentialsSyncer)
+	}
+
+	var vpaSyncer syncer.Interface
+	if wp.HasVerticalAutoscaling() {
+		vpaSyncer = sync.NewVPASyncer(wp, r.Client)
+		syncers = append(syncers, vpaSyncer)
+	}
+
+	extraSyncers, extraErr := r.extraResourceSyncers(wp, syncers)
+	syncers = append(syncers, extraSyncers...)
+
+	// the syncers run independently, so a failing one doesn't block the others
+	syncErr := r.sync(ctx, wp, syncers)
+	if extraErr != nil {
+		syncErr = utilerrors.NewAggregate([]error{syncErr, extraErr})
+	}
+
+	switch {
+	case syncErr == nil:
+		wp.SetCondition(wordpressv1alpha1.ResourcesSyncedCondition, corev1.ConditionTrue,
+			wordpressv1alpha1.ResourcesSyncedReason, "all the site resources are reconciled")
+	case sync.IsTerminal(syncErr):
+		wp.SetCondition(wordpressv1alpha1.ResourcesSyncedCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.InvalidSpecReason, syncErr.Error())
+	default:
+		wp.SetCondition(wordpressv1alpha1.ResourcesSyncedCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.ResourcesSyncFailedReason, syncErr.Error())
+	}
+
+	// the deployment is kept while waiting for the database credentials
+	tracked := syncers
+	if !dbSecretReady {
+		tracked = append(tracked, deploySyncer)
+	}
+
+	// the errors of the steps following the sync are returned only after the
+	// status gets updated, so that the work done so far is not lost
+	var errs []error
+
+	resources, err := r.resourceReferences(tracked)
+	if err != nil {
+		errs = append(errs, err)
+		resources = oldStatus.Resources
+	}
+
+	// the objects of the invalid extra resources are kept until they get fixed
+	if extraErr != nil {
+		resources = mergeResourceReferences(resources, oldStatus.Resources)
+	}
+
+	if err = r.collectGarbage(ctx, wp, oldStatus.Resources, resources); err != nil {
+		// the garbage collection is retried on the next reconciliation
+		errs = append(errs, err)
+		resources = mergeResourceReferences(resources, oldStatus.Resources)
+	}
+
+	wp.Status.Resources = resources
+
+	var deploy *appsv1.Deployment
+	if dbSecretReady {
+		deploy = deploySyncer.Object().(*appsv1.Deployment)
+		wp.Status.Replicas = deploy.Status.Replicas
+	}
+
+	usageCheckAfter, err := r.updateUsage(ctx, wp, deploy)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	var lbStatus corev1.LoadBalancerStatus
+
+	switch {
+	case wp.ServiceType() == corev1.ServiceTypeLoadBalancer:
+		lbStatus = serviceSyncer.Object().(*corev1.Service).Status.LoadBalancer
+	case ingressSyncer != nil:
+		lbStatus = ingressSyncer.Object().(*netv1.Ingress).Status.LoadBalancer
+	}
+
+	r.updateLoadBalancerStatus(wp, lbStatus)
+	wp.Status.Internal = wp.IsInternal()
+
+	if vpaSyncer != nil {
+		if err = updateVPARecommendations(wp, vpaSyncer.Object().(*unstructured.Unstructured)); err != nil {
+			errs = append(errs, err)
+		}
+	} else {
+		wp.Status.Autoscaling = nil
+	}
+
+	var siteHealthCheckAfter time.Duration
+
+	if syncErr == nil {
+		var next *corev1.Secret
+		if dbCredentialsSyncer != nil {
+			next = dbCredentialsSyncer.Object().(*corev1.Secret)
+		}
+
+		if err = r.rotateDBCredentials(ctx, wp, secretSyncer.Object().(*corev1.Secret), next, deploy); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err = r.transferContent(ctx, wp); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err = r.reconcileAdminUsers(ctx, wp); err != nil {
+			errs = append(errs, err)
+		}
+
+		if siteHealthCheckAfter, err = r.checkSiteHealth(ctx, wp); err != nil {
+			errs = append(errs, err)
+		}
+
+		wp.Status.ObservedGeneration = wp.Generation
+	}
+
+	if !equality.Semantic.DeepEqual(oldStatus, &wp.Status) {
 		if errUp := r.Status().Update(ctx, wp.Unwrap()); errUp != nil {
-			return reconcile.Result{}, errUp
+			errs = append(errs, errUp)
 		}
 	}
 
+	if len(errs) > 0 {
+		return reconcile.Result{}, utilerrors.NewAggregate(errs)
+	}
+
+	// retry the failed syncers, once the status reflects the failures, unless
+	// retrying is pointless until the spec changes
+	if syncErr != nil {
+		if sync.IsTerminal(syncErr) {
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{}, syncErr
+	}
+
 	// remove old cron job if exists
-	if err = r.cleanupCronJob(ctx, wp); err != nil {
+	if err = r.cleanupCronJob(ctx, wp, wp.ComponentName(wordpress.WordpressCron)); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	if !wp.HasMediaGC() {
+		if err = r.cleanupCronJob(ctx, wp, wp.ComponentName(wordpress.WordpressMediaGC)); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if !wp.HasDBMaintenance() {
+		if err = r.cleanupCronJob(ctx, wp, wp.ComponentName(wordpress.WordpressDBMaintenance)); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if !wp.HasDebugShell() {
+		if err = r.cleanupDebugShell(ctx, wp); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter(imageCheckAfter, siteHealthCheckAfter, usageCheckAfter)}, nil
+}
+
+// requeueAfter returns the shortest of the non-zero durations.
+func requeueAfter(durations ...time.Duration) time.Duration {
+	var after time.Duration
+
+	for _, d := range durations {
+		if d > 0 && (after == 0 || d < after) {
+			after = d
+		}
+	}
+
+	return after
+}
+
+// cleanupDebugShell deletes the debug shell pod once it's no longer requested.
+func (r *ReconcileWordpress) cleanupDebugShell(ctx context.Context, wp *wordpress.Wordpress) error {
+	key := types.NamespacedName{
+		Name:      wp.ComponentName(wordpress.WordpressDebugShell),
+		Namespace: wp.Namespace,
+	}
+
+	pod := &corev1.Pod{}
+
+	if err := r.Get(ctx, key, pod); err != nil {
+		return ignoreNotFound(err)
+	}
+
+	if !isOwnedBy(pod.OwnerReferences, wp) {
+		return nil
+	}
+
+	return ignoreNotFound(r.Delete(ctx, pod))
 }
 
 func ignoreNotFound(err error) error {
@@ -172,14 +580,60 @@ func ignoreNotFound(err error) error {
 	return err
 }
 
-func (r *ReconcileWordpress) sync(ctx context.Context, syncers []syncer.Interface) error {
+// sync runs all the syncers and returns their aggregated errors. Each failure
+// is also recorded as a warning event naming the object which failed.
+func (r *ReconcileWordpress) sync(ctx context.Context, wp *wordpress.Wordpress, syncers []syncer.Interface) error {
+	var errs []error
+
 	for _, s := range syncers {
 		if err := syncer.Sync(ctx, s, r.recorder); err != nil {
-			return err
+			desc := r.describe(s.Object().(client.Object))
+			r.recorder.Eventf(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.ResourcesSyncFailedReason,
+				"failed to reconcile %s: %s", desc, err)
+
+			errs = append(errs, fmt.Errorf("%s: %w", desc, err))
 		}
 	}
 
-	return nil
+	return utilerrors.NewAggregate(errs)
+}
+
+// describe returns the kind and the name of the object, for error messages.
+func (r *ReconcileWordpress) describe(obj client.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, r.scheme)
+	if err != nil {
+		return obj.GetName()
+	}
+
+	return fmt.Sprintf("%s %s", gvk.Kind, obj.GetName())
+}
+
+// databaseSecret returns the Secret holding the database credentials, if the
+// credentials are managed externally, and whether the site can be rolled out.
+func (r *ReconcileWordpress) databaseSecret(ctx context.Context, wp *wordpress.Wordpress) (*corev1.Secret, bool, error) {
+	if !wp.HasExternalDatabaseSecret() {
+		return nil, true, nil
+	}
+
+	key := types.NamespacedName{
+		Name:      wp.ComponentName(wordpress.WordpressDatabaseSecret),
+		Namespace: wp.Namespace,
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, key, secret); errors.IsNotFound(err) {
+		wp.SetCondition(wordpressv1alpha1.DatabaseCredentialsReadyCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.DatabaseSecretNotFoundReason, fmt.Sprintf("waiting for secret %s to be created", key.Name))
+
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	wp.SetCondition(wordpressv1alpha1.DatabaseCredentialsReadyCondition, corev1.ConditionTrue,
+		wordpressv1alpha1.DatabaseSecretReadyReason, "database credentials are available")
+
+	return secret, true, nil
 }
 
 func (r *ReconcileWordpress) maybeMigrate(wp *wordpressv1alpha1.Wordpress) (*wordpressv1alpha1.Wordpress, bool) {
@@ -202,9 +656,9 @@ func (r *ReconcileWordpress) maybeMigrate(wp *wordpressv1alpha1.Wordpress) (*wor
 	return out, needsMigration
 }
 
-func (r *ReconcileWordpress) cleanupCronJob(ctx context.Context, wp *wordpress.Wordpress) error {
+func (r *ReconcileWordpress) cleanupCronJob(ctx context.Context, wp *wordpress.Wordpress, name string) error {
 	cronKey := types.NamespacedName{
-		Name:      wp.ComponentName(wordpress.WordpressCron),
+		Name:      name,
 		Namespace: wp.Namespace,
 	}
 
@@ -223,7 +677,8 @@ func (r *ReconcileWordpress) cleanupCronJob(ctx context.Context, wp *wordpress.W
 
 func isOwnedBy(refs []metav1.OwnerReference, owner *wordpress.Wordpress) bool {
 	for _, ref := range refs {
-		if (ref.Kind == "Wordpress" || ref.Kind == "wordpress") && ref.Name == owner.Name {
+		// the name is not enough, the object may belong to a deleted site with the same name
+		if ref.UID == owner.UID {
 			return true
 		}
 	}
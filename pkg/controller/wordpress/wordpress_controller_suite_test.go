@@ -52,7 +52,11 @@ var _ = BeforeSuite(func() {
 	var err error
 
 	t = &envtest.Environment{
-		CRDDirectoryPaths: []string{filepath.Join("..", "..", "..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "..", "config", "crd", "bases"),
+			// the CRDs of the third party objects created by the operator
+			filepath.Join("testdata", "crds"),
+		},
 	}
 
 	Expect(apis.AddToScheme(scheme.Scheme)).To(Succeed())
@@ -20,6 +20,10 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -27,16 +31,24 @@ import (
 	. "github.com/onsi/gomega"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/registry"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
 )
 
 const timeout = time.Second * 5
@@ -104,13 +116,15 @@ var _ = Describe("Wordpress controller", func() {
 				},
 			}
 
-			wp.Spec.CodeVolumeSpec.PersistentVolumeClaim = &corev1.PersistentVolumeClaimSpec{
-				AccessModes: []corev1.PersistentVolumeAccessMode{
-					corev1.ReadWriteOnce,
-				},
-				Resources: corev1.ResourceRequirements{
-					Requests: corev1.ResourceList{
-						corev1.ResourceStorage: resource.MustParse("1Gi"),
+			wp.Spec.CodeVolumeSpec.PersistentVolumeClaim = &wordpressv1alpha1.CodePersistentVolumeClaimSpec{
+				PersistentVolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{
+						corev1.ReadWriteOnce,
+					},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("1Gi"),
+						},
 					},
 				},
 			}
@@ -181,6 +195,1547 @@ var _ = Describe("Wordpress controller", func() {
 
 			Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
 			Expect(deploy.Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType))
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.DeploymentStrategy = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+			Expect(deploy.Spec.Strategy.Type).To(Equal(appsv1.RollingUpdateDeploymentStrategyType))
+		})
+
+		It("sets the rollout parameters of the deployment", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			deploy := &appsv1.Deployment{}
+			Eventually(func() error { return c.Get(context.TODO(), key, deploy) }, timeout).Should(Succeed())
+			Expect(*deploy.Spec.RevisionHistoryLimit).To(Equal(int32(3)))
+			Expect(*deploy.Spec.ProgressDeadlineSeconds).To(Equal(int32(600)))
+
+			minReadySeconds := int32(10)
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.MinReadySeconds = &minReadySeconds
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+			Expect(deploy.Spec.MinReadySeconds).To(Equal(minReadySeconds))
+		})
+
+		It("pins the image digest and rolls out the site when the tag moves", func() {
+			var digest atomic.Value
+			digest.Store("sha256:1111111111111111111111111111111111111111111111111111111111111111")
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/bitpoke/site/manifests/v1" {
+					w.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+				w.Header().Set("Docker-Content-Digest", digest.Load().(string))
+			}))
+			defer server.Close()
+
+			defaultRegistryClient := newRegistryClient
+			newRegistryClient = func(creds map[string]registry.Credentials) *registry.Client {
+				return &registry.Client{Credentials: creds, Insecure: true}
+			}
+			defer func() { newRegistryClient = defaultRegistryClient }()
+
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			image := strings.TrimPrefix(server.URL, "http://") + "/bitpoke/site:v1"
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Image = image
+			wp.Spec.ImagePolicy = &wordpressv1alpha1.ImagePolicySpec{
+				PinDigest:     true,
+				CheckInterval: &metav1.Duration{Duration: time.Second},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			deployImage := func() string {
+				// unblock the requeued reconciliations
+				select {
+				case <-requests:
+				default:
+				}
+
+				deploy := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+
+				return deploy.Spec.Template.Spec.Containers[0].Image
+			}
+			Eventually(deployImage, timeout).Should(Equal(image + "@" + digest.Load().(string)))
+
+			Eventually(func() string {
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				if wp.Status.Image == nil {
+					return ""
+				}
+				return wp.Status.Image.Digest
+			}, timeout).Should(Equal(digest.Load().(string)))
+
+			digest.Store("sha256:2222222222222222222222222222222222222222222222222222222222222222")
+			Eventually(deployImage, timeout).Should(Equal(image + "@" + digest.Load().(string)))
+		})
+
+		// nolint: errcheck
+		It("doesn't enforce replicas when the deployment is scaled by a HorizontalPodAutoscaler", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			deploy := &appsv1.Deployment{}
+			Eventually(func() error { return c.Get(context.TODO(), key, deploy) }, timeout).Should(Succeed())
+
+			hpa := &autoscalingv1.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: wp.Name, Namespace: wp.Namespace},
+				Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       wp.Name,
+					},
+					MaxReplicas: 5,
+				},
+			}
+			Expect(c.Create(context.TODO(), hpa)).To(Succeed())
+			defer c.Delete(context.TODO(), hpa)
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			replicas := int32(3)
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Replicas = &replicas
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+			Expect(*deploy.Spec.Replicas).To(Equal(int32(1)))
+
+			Eventually(func() corev1.ConditionStatus {
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				for _, cond := range wp.Status.Conditions {
+					if cond.Type == wordpressv1alpha1.ReplicasConflictCondition {
+						return cond.Status
+					}
+				}
+				return corev1.ConditionUnknown
+			}, timeout).Should(Equal(corev1.ConditionTrue))
+		})
+
+		It("manages the VerticalPodAutoscaler and reports its recommendations", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Autoscaling = &wordpressv1alpha1.AutoscalingSpec{
+				Vertical: &wordpressv1alpha1.VerticalAutoscalingSpec{
+					MaxAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			vpa := &unstructured.Unstructured{}
+			vpa.SetAPIVersion("autoscaling.k8s.io/v1")
+			vpa.SetKind("VerticalPodAutoscaler")
+			Eventually(func() error { return c.Get(context.TODO(), key, vpa) }, timeout).Should(Succeed())
+
+			field := func(fields ...string) string {
+				value, _, err := unstructured.NestedString(vpa.Object, fields...)
+				Expect(err).NotTo(HaveOccurred())
+
+				return value
+			}
+			Expect(field("spec", "targetRef", "kind")).To(Equal("Deployment"))
+			Expect(field("spec", "targetRef", "name")).To(Equal(wp.Name))
+			Expect(field("spec", "updatePolicy", "updateMode")).To(Equal("Off"))
+
+			policies, _, err := unstructured.NestedSlice(vpa.Object, "spec", "resourcePolicy", "containerPolicies")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(ConsistOf(map[string]interface{}{
+				"containerName": "wordpress",
+				"maxAllowed":    map[string]interface{}{"cpu": "2"},
+			}))
+			Expect(vpa.GetOwnerReferences()).To(HaveLen(1))
+			Expect(vpa.GetOwnerReferences()[0].Name).To(Equal(wp.Name))
+
+			// the VerticalPodAutoscaler publishes its recommendations
+			Expect(unstructured.SetNestedSlice(vpa.Object, []interface{}{
+				map[string]interface{}{
+					"containerName": "wordpress",
+					"target":        map[string]interface{}{"cpu": "500m", "memory": "256Mi"},
+					"upperBound":    map[string]interface{}{"cpu": "1", "memory": "512Mi"},
+				},
+			}, "status", "recommendation", "containerRecommendations")).To(Succeed())
+			Expect(c.Update(context.TODO(), vpa)).To(Succeed())
+
+			Eventually(func() *wordpressv1alpha1.AutoscalingStatus {
+				// unblock the reconciliations and trigger a new one, to pick
+				// up the recommendations
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				if wp.Status.Autoscaling == nil {
+					metav1.SetMetaDataAnnotation(&wp.ObjectMeta, "test.presslabs.org/touch", time.Now().String())
+					_ = c.Update(context.TODO(), wp)
+				}
+
+				return wp.Status.Autoscaling
+			}, timeout).ShouldNot(BeNil())
+
+			Expect(wp.Status.Autoscaling.Recommendations).To(HaveLen(1))
+			recommendation := wp.Status.Autoscaling.Recommendations[0]
+			Expect(recommendation.ContainerName).To(Equal("wordpress"))
+			Expect(recommendation.Target.Cpu().String()).To(Equal("500m"))
+			Expect(recommendation.Target.Memory().String()).To(Equal("256Mi"))
+			Expect(recommendation.UpperBound.Cpu().String()).To(Equal("1"))
+			Expect(recommendation.LowerBound).To(BeEmpty())
+
+			// the recommendations are dropped along with the VerticalPodAutoscaler
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Autoscaling = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			Eventually(func() *wordpressv1alpha1.AutoscalingStatus {
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				return wp.Status.Autoscaling
+			}, timeout).Should(BeNil())
+		})
+
+		// nolint: errcheck
+		It("manages the KEDA ScaledObject and leaves the replicas to it", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			deploy := &appsv1.Deployment{}
+			Eventually(func() error { return c.Get(context.TODO(), key, deploy) }, timeout).Should(Succeed())
+
+			// KEDA scales the deployment
+			Eventually(func() error {
+				Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+				replicas := int32(4)
+				deploy.Spec.Replicas = &replicas
+
+				return c.Update(context.TODO(), deploy)
+			}, timeout).Should(Succeed())
+
+			replicas := int32(2)
+			minReplicas := int32(0)
+			pollingInterval := int32(15)
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Replicas = &replicas
+			wp.Spec.Autoscaling = &wordpressv1alpha1.AutoscalingSpec{
+				KEDA: &wordpressv1alpha1.KEDAAutoscalingSpec{
+					MinReplicas:     &minReplicas,
+					MaxReplicas:     5,
+					PollingInterval: &pollingInterval,
+					Triggers: []wordpressv1alpha1.KEDATrigger{
+						{
+							Type:              "prometheus",
+							Metadata:          map[string]string{"threshold": "100"},
+							AuthenticationRef: "prometheus",
+						},
+					},
+				},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			scaledObject := &unstructured.Unstructured{}
+			scaledObject.SetAPIVersion("keda.sh/v1alpha1")
+			scaledObject.SetKind("ScaledObject")
+			Eventually(func() error { return c.Get(context.TODO(), key, scaledObject) }, timeout).Should(Succeed())
+			defer c.Delete(context.TODO(), scaledObject)
+
+			spec, _, err := unstructured.NestedMap(scaledObject.Object, "spec")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec).To(Equal(map[string]interface{}{
+				"scaleTargetRef":  map[string]interface{}{"name": wp.Name},
+				"minReplicaCount": int64(0),
+				"maxReplicaCount": int64(5),
+				"pollingInterval": int64(15),
+				"triggers": []interface{}{
+					map[string]interface{}{
+						"type":              "prometheus",
+						"metadata":          map[string]interface{}{"threshold": "100"},
+						"authenticationRef": map[string]interface{}{"name": "prometheus"},
+					},
+				},
+			}))
+			Expect(scaledObject.GetOwnerReferences()).To(HaveLen(1))
+			Expect(scaledObject.GetOwnerReferences()[0].Name).To(Equal(wp.Name))
+
+			// spec.replicas is ignored while the deployment is scaled by KEDA
+			Consistently(func() int32 {
+				// unblock the reconciliations
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+
+				return *deploy.Spec.Replicas
+			}).Should(Equal(int32(4)))
+		})
+
+		// nolint: errcheck
+		It("waits for the database credentials materialized by the ExternalSecret", func() {
+			name := fmt.Sprintf("%s-eso", wp.Name)
+			key := types.NamespacedName{Name: name, Namespace: wp.Namespace}
+			secretKey := types.NamespacedName{Name: name + "-db", Namespace: wp.Namespace}
+
+			site := &wordpressv1alpha1.Wordpress{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: wp.Namespace},
+				Spec: wordpressv1alpha1.WordpressSpec{
+					Routes: []wordpressv1alpha1.RouteSpec{{Domain: fmt.Sprintf("%s.example.com", name)}},
+					Database: &wordpressv1alpha1.DatabaseSpec{
+						ExternalSecretRef: &wordpressv1alpha1.ExternalSecretRef{StoreName: "vault", Key: "sites/" + name},
+					},
+				},
+			}
+			Expect(c.Create(context.TODO(), site)).To(Succeed())
+			defer c.Delete(context.TODO(), site)
+
+			get := func(objKey types.NamespacedName, obj client.Object) func() error {
+				return func() error {
+					// unblock the reconciliations of both sites
+					select {
+					case <-requests:
+					default:
+					}
+
+					return c.Get(context.TODO(), objKey, obj)
+				}
+			}
+
+			externalSecret := &unstructured.Unstructured{}
+			externalSecret.SetAPIVersion("external-secrets.io/v1beta1")
+			externalSecret.SetKind("ExternalSecret")
+			Eventually(get(secretKey, externalSecret), timeout).Should(Succeed())
+			defer c.Delete(context.TODO(), externalSecret)
+
+			target, _, err := unstructured.NestedMap(externalSecret.Object, "spec", "target")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(HaveKeyWithValue("name", secretKey.Name))
+			// the secret is deleted along with the ExternalSecret
+			Expect(target).To(HaveKeyWithValue("creationPolicy", "Owner"))
+
+			secretLabels, _, err := unstructured.NestedStringMap(target, "template", "metadata", "labels")
+			Expect(err).NotTo(HaveOccurred())
+			// the labels map the secret back to the site, which must be found in the restricted cache
+			Expect(secretLabels).To(HaveKeyWithValue("app.kubernetes.io/instance", name))
+			Expect(secretLabels).To(HaveKeyWithValue("app.kubernetes.io/component", "database"))
+			Expect(secretLabels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "wordpress-operator.presslabs.org"))
+
+			storeRef, _, err := unstructured.NestedMap(externalSecret.Object, "spec", "secretStoreRef")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storeRef).To(Equal(map[string]interface{}{"name": "vault", "kind": "SecretStore"}))
+			Expect(externalSecret.GetOwnerReferences()).To(HaveLen(1))
+			Expect(externalSecret.GetOwnerReferences()[0].Name).To(Equal(name))
+
+			// the deployment waits for the database credentials
+			Consistently(get(key, &appsv1.Deployment{})).ShouldNot(Succeed())
+
+			dbCondition := func() string {
+				Expect(get(key, site)()).To(Succeed())
+
+				for _, cond := range site.Status.Conditions {
+					if cond.Type == wordpressv1alpha1.DatabaseCredentialsReadyCondition {
+						return fmt.Sprintf("%s/%s", cond.Status, cond.Reason)
+					}
+				}
+
+				return ""
+			}
+			Eventually(dbCondition, timeout).Should(Equal("False/" + wordpressv1alpha1.DatabaseSecretNotFoundReason))
+
+			// the External Secrets Operator materializes the credentials
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace, Labels: secretLabels},
+				StringData: map[string]string{"DB_PASSWORD": "secret"},
+			}
+			Expect(c.Create(context.TODO(), secret)).To(Succeed())
+			defer c.Delete(context.TODO(), secret)
+
+			deploy := &appsv1.Deployment{}
+			Eventually(get(key, deploy), timeout).Should(Succeed())
+			defer c.Delete(context.TODO(), deploy)
+			Expect(deploy.Spec.Template.Annotations).To(HaveKey(wordpress.DBSecretChecksumAnnotation))
+			Eventually(dbCondition, timeout).Should(Equal("True/" + wordpressv1alpha1.DatabaseSecretReadyReason))
+		})
+
+		It("exports the site content when requested through the annotation", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			url := "https://storage.example.com/export.xml?signature=secret"
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Annotations = map[string]string{wordpress.ExportContentAnnotation: url}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			job := &batchv1.Job{}
+			jobKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-export-%s", wp.Name, wordpress.ContentTransferToken(url)[:8]),
+				Namespace: wp.Namespace,
+			}
+			Eventually(func() error { return c.Get(context.TODO(), jobKey, job) }, timeout).Should(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "CONTENT_URL", Value: url}))
+
+			job.Status.Succeeded = 1
+			Expect(c.Status().Update(context.TODO(), job)).To(Succeed())
+
+			Eventually(func() string {
+				// unblock the reconciliations triggered by the job and status updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				if wp.Status.Content == nil || wp.Status.Content.Export == nil {
+					return ""
+				}
+				return wp.Status.Content.Export.Token
+			}, timeout).Should(Equal(wordpress.ContentTransferToken(url)))
+		})
+
+		It("rotates the database credentials when requested through the annotation", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			// get unblocks the reconciliations triggered by the updates and gets the object
+			get := func(key types.NamespacedName, obj client.Object) func() error {
+				return func() error {
+					select {
+					case <-requests:
+					default:
+					}
+
+					return c.Get(context.TODO(), key, obj)
+				}
+			}
+
+			rotation := func() *wordpressv1alpha1.WordpressCondition {
+				Expect(get(key, wp)()).To(Succeed())
+				for i := range wp.Status.Conditions {
+					if wp.Status.Conditions[i].Type == wordpressv1alpha1.DBCredentialsRotationCondition {
+						return &wp.Status.Conditions[i]
+					}
+				}
+				return nil
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Annotations = map[string]string{wordpress.RotateDBCredentialsAnnotation: "1"}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			// the new password is kept out of the site secret
+			next := &corev1.Secret{}
+			nextKey := types.NamespacedName{Name: fmt.Sprintf("%s-db-credentials", wp.Name), Namespace: wp.Namespace}
+			Eventually(get(nextKey, next), timeout).Should(Succeed())
+			Expect(next.Data).To(HaveKey(wordpress.NextDBPasswordKey))
+
+			secret := &corev1.Secret{}
+			secretKey := types.NamespacedName{Name: fmt.Sprintf("%s-wp", wp.Name), Namespace: wp.Namespace}
+			Expect(c.Get(context.TODO(), secretKey, secret)).To(Succeed())
+			Expect(secret.Data).NotTo(HaveKey(wordpress.NextDBPasswordKey))
+
+			job := &batchv1.Job{}
+			jobKey := types.NamespacedName{
+				Name:      wordpress.New(wp).ComponentName(wordpress.WordpressDBCredentialsRotation),
+				Namespace: wp.Namespace,
+			}
+			Eventually(get(jobKey, job), timeout).Should(Succeed())
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+				Name: "NEXT_DB_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: nextKey.Name},
+						Key:                  wordpress.NextDBPasswordKey,
+					},
+				},
+			}))
+
+			job.Status.Succeeded = 1
+			Expect(c.Status().Update(context.TODO(), job)).To(Succeed())
+
+			// the password gets promoted, but the old one is still retained
+			Eventually(func() []byte {
+				Expect(get(secretKey, secret)()).To(Succeed())
+				return secret.Data[wordpress.DBPasswordKey]
+			}, timeout).Should(Equal(next.Data[wordpress.NextDBPasswordKey]))
+
+			Eventually(func() bool {
+				Expect(get(key, wp)()).To(Succeed())
+				return wp.Status.Database != nil && wp.Status.Database.OldCredentialsRetained
+			}, timeout).Should(BeTrue())
+			Expect(rotation().Status).To(Equal(corev1.ConditionUnknown))
+
+			// the pods roll out with the new password
+			deploy := &appsv1.Deployment{}
+			Eventually(func() string {
+				Expect(get(key, deploy)()).To(Succeed())
+				return deploy.Spec.Template.Annotations[wordpress.SecretChecksumAnnotation]
+			}, timeout).Should(Equal(wordpress.SecretChecksum(secret)))
+
+			discardKey := types.NamespacedName{
+				Name:      wordpress.New(wp).ComponentName(wordpress.WordpressDBCredentialsDiscard),
+				Namespace: wp.Namespace,
+			}
+			Consistently(get(discardKey, &batchv1.Job{})).ShouldNot(Succeed())
+
+			deploy.Status.ObservedGeneration = deploy.Generation
+			Expect(c.Status().Update(context.TODO(), deploy)).To(Succeed())
+
+			// the old password gets discarded after the rollout
+			Eventually(get(discardKey, job), timeout).Should(Succeed())
+
+			job.Status.Succeeded = 1
+			Expect(c.Status().Update(context.TODO(), job)).To(Succeed())
+
+			Eventually(func() corev1.ConditionStatus {
+				if cond := rotation(); cond != nil {
+					return cond.Status
+				}
+				return corev1.ConditionUnknown
+			}, timeout).Should(Equal(corev1.ConditionTrue))
+			Expect(wp.Status.Database.OldCredentialsRetained).To(BeFalse())
+			Expect(wp.Status.Database.CredentialsRotationToken).To(Equal("1"))
+
+			// the pending password is no longer kept
+			Eventually(get(nextKey, next), timeout).ShouldNot(Succeed())
+		})
+
+		It("fails the database credentials rotation when the current password can't be retained", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			// get unblocks the reconciliations triggered by the updates and gets the object
+			get := func(key types.NamespacedName, obj client.Object) func() error {
+				return func() error {
+					select {
+					case <-requests:
+					default:
+					}
+
+					return c.Get(context.TODO(), key, obj)
+				}
+			}
+
+			secret := &corev1.Secret{}
+			secretKey := types.NamespacedName{Name: fmt.Sprintf("%s-wp", wp.Name), Namespace: wp.Namespace}
+			Eventually(get(secretKey, secret), timeout).Should(Succeed())
+			password := secret.Data[wordpress.DBPasswordKey]
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Annotations = map[string]string{wordpress.RotateDBCredentialsAnnotation: "1"}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			job := &batchv1.Job{}
+			jobKey := types.NamespacedName{
+				Name:      wordpress.New(wp).ComponentName(wordpress.WordpressDBCredentialsRotation),
+				Namespace: wp.Namespace,
+			}
+			Eventually(get(jobKey, job), timeout).Should(Succeed())
+
+			// the password is never changed without retaining the current one
+			script := strings.Join(job.Spec.Template.Spec.Containers[0].Args, " ")
+			Expect(script).To(ContainSubstring("RETAIN CURRENT PASSWORD"))
+			Expect(script).NotTo(ContainSubstring("||"))
+
+			job.Status.Failed = 1
+			Expect(c.Status().Update(context.TODO(), job)).To(Succeed())
+
+			Eventually(func() string {
+				Expect(get(key, wp)()).To(Succeed())
+				for _, cond := range wp.Status.Conditions {
+					if cond.Type == wordpressv1alpha1.DBCredentialsRotationCondition && cond.Status == corev1.ConditionFalse {
+						return cond.Reason
+					}
+				}
+				return ""
+			}, timeout).Should(Equal(wordpressv1alpha1.DBCredentialsRotationFailedReason))
+
+			// the pods keep using the current password
+			Expect(get(secretKey, secret)()).To(Succeed())
+			Expect(secret.Data[wordpress.DBPasswordKey]).To(Equal(password))
+			Expect(wp.Status.Database).To(BeNil())
+		})
+
+		It("manages the media garbage collection cron job", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			cronKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-media-gc", wp.Name),
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			successfulJobsHistoryLimit := int32(3)
+			wp.Spec.MediaGC = &wordpressv1alpha1.MediaGCSpec{DryRun: true, SuccessfulJobsHistoryLimit: &successfulJobsHistoryLimit}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			cronJob := &batchv1beta1.CronJob{}
+			Eventually(func() error { return c.Get(context.TODO(), cronKey, cronJob) }, timeout).Should(Succeed())
+			Expect(cronJob.Spec.Schedule).To(Equal("0 3 * * 0"))
+			Expect(*cronJob.Spec.SuccessfulJobsHistoryLimit).To(Equal(int32(3)))
+			Expect(*cronJob.Spec.FailedJobsHistoryLimit).To(Equal(int32(1)))
+			Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(batchv1beta1.ForbidConcurrent))
+			Expect(cronJob.Spec.StartingDeadlineSeconds).To(BeNil())
+			Expect(*cronJob.Spec.Suspend).To(BeFalse())
+			Expect(*cronJob.Spec.JobTemplate.Spec.BackoffLimit).To(Equal(options.JobBackoffLimit))
+			Expect(options.JobBackoffLimit).To(BeNumerically(">", 0))
+			Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "DRY_RUN", Value: "true"}))
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.MediaGC = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Eventually(func() error { return c.Get(context.TODO(), cronKey, cronJob) }, timeout).ShouldNot(Succeed())
+		})
+
+		It("manages the database maintenance cron job", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			cronKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-db-maintenance", wp.Name),
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			startingDeadlineSeconds := int64(600)
+			wp.Spec.DBMaintenance = &wordpressv1alpha1.DBMaintenanceSpec{
+				Schedule:                "30 2 * * *",
+				DeleteAllTransients:     true,
+				ConcurrencyPolicy:       batchv1beta1.ReplaceConcurrent,
+				StartingDeadlineSeconds: &startingDeadlineSeconds,
+				Suspend:                 true,
+			}
+			wp.Spec.Components = &wordpressv1alpha1.ComponentsSpec{
+				Cron: &wordpressv1alpha1.ComponentSpec{NodeSelector: map[string]string{"node-lifecycle": "spot"}},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			cronJob := &batchv1beta1.CronJob{}
+			Eventually(func() error { return c.Get(context.TODO(), cronKey, cronJob) }, timeout).Should(Succeed())
+			Expect(cronJob.Spec.Schedule).To(Equal("30 2 * * *"))
+			Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(batchv1beta1.ReplaceConcurrent))
+			Expect(*cronJob.Spec.StartingDeadlineSeconds).To(Equal(int64(600)))
+			Expect(*cronJob.Spec.Suspend).To(BeTrue())
+			Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.NodeSelector).To(HaveKeyWithValue("node-lifecycle", "spot"))
+			Expect(*cronJob.Spec.SuccessfulJobsHistoryLimit).To(Equal(int32(1)))
+			Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "DELETE_ALL_TRANSIENTS", Value: "true"}))
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.DBMaintenance = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Eventually(func() error { return c.Get(context.TODO(), cronKey, cronJob) }, timeout).ShouldNot(Succeed())
 		})
+
+		// nolint: errcheck
+		It("reports the Site Health test results in the status", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "app-password" {
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+
+				switch r.URL.Path {
+				case "/wp-json/wp-site-health/v1/tests/https-status":
+					fmt.Fprint(w, `{"status":"critical","label":"Your website does not use HTTPS"}`)
+				case "/wp-json/wp-site-health/v1/tests/background-updates":
+					fmt.Fprint(w, `{"status":"recommended","label":"Background updates may not be working properly"}`)
+				default:
+					fmt.Fprint(w, `{"status":"good","label":"Passed"}`)
+				}
+			}))
+			defer server.Close()
+
+			defaultSiteHealthHost := siteHealthHost
+			siteHealthHost = func(*wordpress.Wordpress) string {
+				return strings.TrimPrefix(server.URL, "http://")
+			}
+			defer func() { siteHealthHost = defaultSiteHealthHost }()
+
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: wp.Name + "-app-password", Namespace: wp.Namespace},
+				StringData: map[string]string{
+					"username": "admin",
+					"password": "app-password",
+				},
+			}
+			Expect(c.Create(context.TODO(), secret)).To(Succeed())
+			defer c.Delete(context.TODO(), secret)
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.SiteHealth = &wordpressv1alpha1.SiteHealthSpec{
+				ApplicationPasswordSecretRef: corev1.LocalObjectReference{Name: secret.Name},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			Eventually(func() *wordpressv1alpha1.SiteHealthStatus {
+				// unblock the reconciliations triggered by the status updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				return wp.Status.SiteHealth
+			}, timeout).ShouldNot(BeNil())
+
+			Expect(wp.Status.SiteHealth.Critical).To(Equal(int32(1)))
+			Expect(wp.Status.SiteHealth.Recommended).To(Equal(int32(1)))
+			Expect(wp.Status.SiteHealth.Issues).To(ContainElement(wordpressv1alpha1.SiteHealthIssue{
+				Test:   "https-status",
+				Status: "critical",
+				Label:  "Your website does not use HTTPS",
+			}))
+
+			var healthy *wordpressv1alpha1.WordpressCondition
+			for i := range wp.Status.Conditions {
+				if wp.Status.Conditions[i].Type == wordpressv1alpha1.SiteHealthyCondition {
+					healthy = &wp.Status.Conditions[i]
+				}
+			}
+			Expect(healthy).NotTo(BeNil())
+			Expect(healthy.Status).To(Equal(corev1.ConditionFalse))
+			Expect(healthy.Reason).To(Equal(wordpressv1alpha1.SiteHealthCriticalIssuesReason))
+		})
+
+		It("reports missing Site Health credentials in the status", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.SiteHealth = &wordpressv1alpha1.SiteHealthSpec{
+				ApplicationPasswordSecretRef: corev1.LocalObjectReference{Name: wp.Name + "-missing"},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			Eventually(func() *wordpressv1alpha1.SiteHealthStatus {
+				// unblock the reconciliations triggered by the status updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				return wp.Status.SiteHealth
+			}, timeout).ShouldNot(BeNil())
+
+			Expect(wp.Status.SiteHealth.LastCheckTime).NotTo(BeNil())
+
+			var healthy *wordpressv1alpha1.WordpressCondition
+			for i := range wp.Status.Conditions {
+				if wp.Status.Conditions[i].Type == wordpressv1alpha1.SiteHealthyCondition {
+					healthy = &wp.Status.Conditions[i]
+				}
+			}
+			Expect(healthy).NotTo(BeNil())
+			Expect(healthy.Status).To(Equal(corev1.ConditionUnknown))
+			Expect(healthy.Reason).To(Equal(wordpressv1alpha1.SiteHealthCredentialsMissingReason))
+		})
+
+		// nolint: errcheck
+		It("rolls the web pods when the envFrom sources change", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: wp.Name + "-env", Namespace: wp.Namespace},
+				Data:       map[string]string{"WP_ENV": "staging"},
+			}
+			Expect(c.Create(context.TODO(), cm)).To(Succeed())
+			defer c.Delete(context.TODO(), cm)
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.EnvFrom = []corev1.EnvFromSource{
+				{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: cm.Name}}},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			envFromChecksum := func() string {
+				// unblock the reconciliations triggered by the deployment updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				deploy := &appsv1.Deployment{}
+				Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+
+				return deploy.Spec.Template.Annotations[wordpress.EnvFromChecksumAnnotation]
+			}
+			Eventually(envFromChecksum, timeout).ShouldNot(BeEmpty())
+			checksum := envFromChecksum()
+
+			cm.Data["WP_ENV"] = "production"
+			Expect(c.Update(context.TODO(), cm)).To(Succeed())
+
+			Eventually(envFromChecksum, timeout).ShouldNot(Equal(checksum))
+		})
+
+		It("manages the debug shell pod through the annotation", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			podKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-debug", wp.Name),
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Annotations = map[string]string{wordpress.DebugShellAnnotation: "true"}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			pod := &corev1.Pod{}
+			Eventually(func() error { return c.Get(context.TODO(), podKey, pod) }, timeout).Should(Succeed())
+			Expect(pod.Spec.Containers[0].Image).To(Equal(options.WordpressRuntimeImage))
+			Expect(pod.Spec.Containers[0].Args).To(Equal([]string{"sleep", "infinity"}))
+			Expect(*pod.Spec.ActiveDeadlineSeconds).To(Equal(options.DebugShellTTLSeconds))
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			delete(wp.Annotations, wordpress.DebugShellAnnotation)
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			Eventually(func() error {
+				// unblock the reconciliations triggered by the updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				return c.Get(context.TODO(), podKey, pod)
+			}, timeout).ShouldNot(Succeed())
+		})
+
+		// nolint: errcheck
+		It("provisions a dedicated namespace for the sites in the blueprint namespace", func() {
+			blueprint := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("blueprint-%d", rand.Int31())}}
+			Expect(c.Create(context.TODO(), blueprint)).To(Succeed())
+
+			options.BlueprintNamespace = blueprint.Name
+			defer func() { options.BlueprintNamespace = "" }()
+
+			quota := &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: blueprint.Name},
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+				},
+			}
+			Expect(c.Create(context.TODO(), quota)).To(Succeed())
+
+			site := &wordpressv1alpha1.Wordpress{
+				ObjectMeta: metav1.ObjectMeta{Name: wp.Name, Namespace: blueprint.Name},
+				Spec:       wordpressv1alpha1.WordpressSpec{Routes: wp.Spec.Routes},
+			}
+			Expect(c.Create(context.TODO(), site)).To(Succeed())
+			defer c.Delete(context.TODO(), site)
+
+			nsName := options.ProvisionedNamespacePrefix + wp.Name
+			get := func(key types.NamespacedName, obj client.Object) func() error {
+				return func() error {
+					// unblock the reconciliations of both sites
+					select {
+					case <-requests:
+					default:
+					}
+
+					return c.Get(context.TODO(), key, obj)
+				}
+			}
+
+			ns := &corev1.Namespace{}
+			Eventually(get(types.NamespacedName{Name: nsName}, ns), timeout).Should(Succeed())
+			Expect(ns.Labels).To(HaveKeyWithValue(wordpress.BlueprintLabel, wp.Name))
+
+			copiedQuota := &corev1.ResourceQuota{}
+			Eventually(get(types.NamespacedName{Name: quota.Name, Namespace: nsName}, copiedQuota), timeout).Should(Succeed())
+			Expect(copiedQuota.Spec.Hard).To(Equal(quota.Spec.Hard))
+
+			copiedSite := &wordpressv1alpha1.Wordpress{}
+			Eventually(get(types.NamespacedName{Name: wp.Name, Namespace: nsName}, copiedSite), timeout).Should(Succeed())
+			Expect(copiedSite.Spec.Routes).To(Equal(site.Spec.Routes))
+
+			Eventually(func() string {
+				Expect(get(types.NamespacedName{Name: site.Name, Namespace: site.Namespace}, site)()).To(Succeed())
+
+				return site.Status.ProvisionedNamespace
+			}, timeout).Should(Equal(nsName))
+
+			// the blueprint site is not deployed in the blueprint namespace
+			Expect(c.Get(context.TODO(), types.NamespacedName{Name: wp.Name, Namespace: blueprint.Name}, &appsv1.Deployment{})).ToNot(Succeed())
+		})
+
+		It("deletes the resources no longer needed by the site", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			cmKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-php-config", wp.Name),
+				Namespace: wp.Namespace,
+			}
+
+			getConfigMap := func() error {
+				// unblock the reconciliations triggered by the updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				return c.Get(context.TODO(), cmKey, &corev1.ConfigMap{})
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.PHPConfig = map[string]string{"memory_limit": "256M"}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(getConfigMap, timeout).Should(Succeed())
+
+			Eventually(func() []wordpressv1alpha1.ResourceReference {
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				return wp.Status.Resources
+			}, timeout).Should(ContainElement(wordpressv1alpha1.ResourceReference{APIVersion: "v1", Kind: "ConfigMap", Name: cmKey.Name}))
+
+			wp.Spec.PHPConfig = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(getConfigMap, timeout).ShouldNot(Succeed())
+		})
+
+		It("releases the volume claims no longer used by the site", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			pvcKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-media", wp.Name),
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.MediaVolumeSpec = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+
+			Eventually(func() []wordpressv1alpha1.ResourceReference {
+				// unblock the reconciliations triggered by the updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				return wp.Status.Resources
+			}, timeout).ShouldNot(ContainElement(wordpressv1alpha1.ResourceReference{
+				APIVersion: "v1", Kind: "PersistentVolumeClaim", Name: pvcKey.Name,
+			}))
+
+			// the media is kept, but it's no longer deleted along with the site
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(c.Get(context.TODO(), pvcKey, pvc)).To(Succeed())
+			Expect(pvc.DeletionTimestamp).To(BeNil())
+			Expect(pvc.OwnerReferences).To(BeEmpty())
+		})
+
+		It("tracks the observed generation", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.PHPConfig = map[string]string{"memory_limit": "256M"}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			generation := wp.Generation
+
+			Eventually(func() int64 {
+				// unblock the reconciliations triggered by the update
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				return wp.Status.ObservedGeneration
+			}, timeout).Should(Equal(generation))
+		})
+
+		// nolint: errcheck
+		It("reconciles the other resources when one of them fails", func() {
+			name := fmt.Sprintf("%s-conflict", wp.Name)
+			key := types.NamespacedName{Name: name, Namespace: wp.Namespace}
+
+			// a service with a different selector can't be taken over
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: wp.Namespace},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{"app": "other"},
+					Ports:    []corev1.ServicePort{{Port: 80}},
+				},
+			}
+			Expect(c.Create(context.TODO(), svc)).To(Succeed())
+			defer c.Delete(context.TODO(), svc)
+
+			site := &wordpressv1alpha1.Wordpress{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: wp.Namespace},
+				Spec: wordpressv1alpha1.WordpressSpec{
+					Routes: []wordpressv1alpha1.RouteSpec{{Domain: fmt.Sprintf("%s.example.com", name)}},
+				},
+			}
+			Expect(c.Create(context.TODO(), site)).To(Succeed())
+			defer c.Delete(context.TODO(), site)
+
+			get := func(obj client.Object) func() error {
+				return func() error {
+					// unblock the reconciliations of both sites
+					select {
+					case <-requests:
+					default:
+					}
+
+					return c.Get(context.TODO(), key, obj)
+				}
+			}
+
+			Eventually(get(&appsv1.Deployment{}), timeout).Should(Succeed())
+			Eventually(get(&netv1.Ingress{}), timeout).Should(Succeed())
+
+			Eventually(func() corev1.ConditionStatus {
+				Expect(get(site)()).To(Succeed())
+
+				for _, cond := range site.Status.Conditions {
+					if cond.Type == wordpressv1alpha1.ResourcesSyncedCondition {
+						return cond.Status
+					}
+				}
+
+				return corev1.ConditionUnknown
+			}, timeout).Should(Equal(corev1.ConditionFalse))
+
+			// the resources which were reconciled are tracked despite the failure
+			Expect(site.Status.Resources).To(ContainElement(wordpressv1alpha1.ResourceReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       name,
+			}))
+
+			// the failing object is reported in its own event
+			Eventually(func() []string {
+				events := &corev1.EventList{}
+				Expect(c.List(context.TODO(), events, client.InNamespace(wp.Namespace))).To(Succeed())
+
+				var messages []string
+				for _, event := range events.Items {
+					if event.InvolvedObject.Name == name && event.Reason == wordpressv1alpha1.ResourcesSyncFailedReason {
+						messages = append(messages, event.Message)
+					}
+				}
+
+				return messages
+			}, timeout).Should(ContainElement(ContainSubstring("Service " + name)))
+		})
+
+		It("publishes the ingress load balancer address", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			ingress := &netv1.Ingress{}
+			Expect(c.Get(context.TODO(), key, ingress)).To(Succeed())
+			ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+			Expect(c.Status().Update(context.TODO(), ingress)).To(Succeed())
+
+			Eventually(func() *corev1.LoadBalancerStatus {
+				// unblock the reconciliations triggered by the update
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				return wp.Status.LoadBalancer
+			}, timeout).Should(Equal(&ingress.Status.LoadBalancer))
+		})
+
+		It("configures sticky sessions on the service and the ingress", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Routing = &wordpressv1alpha1.RoutingSpec{
+				StickySessions: &wordpressv1alpha1.StickySessionsSpec{},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			svc := &corev1.Service{}
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(svc.Spec.SessionAffinity).To(Equal(corev1.ServiceAffinityClientIP))
+			Expect(*svc.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds).To(Equal(int32(10800)))
+
+			ingress := &netv1.Ingress{}
+			Expect(c.Get(context.TODO(), key, ingress)).To(Succeed())
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/affinity", "cookie"))
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/session-cookie-name", "wp-route"))
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/session-cookie-max-age", "10800"))
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Routing = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(svc.Spec.SessionAffinity).To(Equal(corev1.ServiceAffinityNone))
+
+			Expect(c.Get(context.TODO(), key, ingress)).To(Succeed())
+			Expect(ingress.Annotations).NotTo(HaveKey("nginx.ingress.kubernetes.io/affinity"))
+		})
+
+		It("exposes the site through the configured service type", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Service = &wordpressv1alpha1.ServiceSpec{
+				Type:        corev1.ServiceTypeNodePort,
+				Annotations: map[string]string{"example.com/lb": "internal"},
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			svc := &corev1.Service{}
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+			Expect(svc.Annotations).To(HaveKeyWithValue("example.com/lb", "internal"))
+			Expect(svc.Spec.Ports[0].NodePort).NotTo(BeZero())
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Service = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			Expect(svc.Spec.Ports[0].NodePort).To(BeZero())
+		})
+
+		It("removes the ingress of internal sites", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			Expect(c.Get(context.TODO(), key, &netv1.Ingress{})).To(Succeed())
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Routing = &wordpressv1alpha1.RoutingSpec{Internal: true}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Eventually(func() error { return c.Get(context.TODO(), key, &netv1.Ingress{}) }, timeout).ShouldNot(Succeed())
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			Expect(wp.Status.Internal).To(BeTrue())
+			Expect(c.Get(context.TODO(), key, &corev1.Service{})).To(Succeed())
+		})
+
+		It("sets the ip family policy of the service", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			policy := corev1.IPFamilyPolicyPreferDualStack
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Service = &wordpressv1alpha1.ServiceSpec{IPFamilyPolicy: &policy}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			svc := &corev1.Service{}
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(*svc.Spec.IPFamilyPolicy).To(Equal(policy))
+			Expect(svc.Spec.IPFamilies).NotTo(BeEmpty())
+		})
+
+		It("toggles the topology aware routing of the service", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Service = &wordpressv1alpha1.ServiceSpec{TopologyAwareRouting: true}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			svc := &corev1.Service{}
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(svc.Annotations).To(HaveKeyWithValue("service.kubernetes.io/topology-aware-hints", "auto"))
+			Expect(svc.Annotations).To(HaveKeyWithValue("service.kubernetes.io/topology-mode", "Auto"))
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Service = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Expect(c.Get(context.TODO(), key, svc)).To(Succeed())
+			Expect(svc.Annotations).NotTo(HaveKey("service.kubernetes.io/topology-aware-hints"))
+			Expect(svc.Annotations).NotTo(HaveKey("service.kubernetes.io/topology-mode"))
+		})
+
+		It("applies the admin users through wp-cli jobs", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			user := wordpressv1alpha1.AdminUserSpec{
+				Username: "admin",
+				Email:    "admin@example.com",
+				Role:     "administrator",
+				PasswordSecretRef: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "admin-password"},
+					Key:                  "password",
+				},
+				PasswordResetToken: "1",
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.AdminUsers = []wordpressv1alpha1.AdminUserSpec{user}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			job := &batchv1.Job{}
+			jobKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-admin-%s", wp.Name, wordpress.AdminUserToken(&user)[:8]),
+				Namespace: wp.Namespace,
+			}
+			Eventually(func() error { return c.Get(context.TODO(), jobKey, job) }, timeout).Should(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "ADMIN_USERNAME", Value: "admin"}))
+			Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "ADMIN_PASSWORD_RESET", Value: "1"}))
+
+			job.Status.Succeeded = 1
+			Expect(c.Status().Update(context.TODO(), job)).To(Succeed())
+
+			Eventually(func() string {
+				// unblock the reconciliations triggered by the job and status updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				if len(wp.Status.AdminUsers) == 0 {
+					return ""
+				}
+				return wp.Status.AdminUsers[0].Token
+			}, timeout).Should(Equal(wordpress.AdminUserToken(&user)))
+			Expect(wp.Status.AdminUsers[0].PasswordResetToken).To(Equal("1"))
+		})
+
+		It("reports the site usage in the status", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			deploy := &appsv1.Deployment{}
+			Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
+			deploy.Status.Replicas = 2
+			Expect(c.Status().Update(context.TODO(), deploy)).To(Succeed())
+
+			Eventually(func() int32 {
+				// unblock the reconciliations triggered by the status updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+				if wp.Status.Usage == nil {
+					return 0
+				}
+				return wp.Status.Usage.Replicas
+			}, timeout).Should(Equal(int32(2)))
+
+			cpu := wp.Status.Usage.Requests[corev1.ResourceCPU]
+			Expect(cpu.MilliValue()).To(BeNumerically(">=", 200))
+		})
+
+		It("manages the extra resources of the site", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+			cmKey := types.NamespacedName{
+				Name:      fmt.Sprintf("%s-cdn", wp.Name),
+				Namespace: wp.Namespace,
+			}
+			cm := &corev1.ConfigMap{}
+
+			getConfigMap := func() error {
+				// unblock the reconciliations triggered by the updates
+				select {
+				case <-requests:
+				default:
+				}
+
+				return c.Get(context.TODO(), cmKey, cm)
+			}
+
+			options.ExtraResourceKinds = []string{"ConfigMap"}
+			defer func() { options.ExtraResourceKinds = nil }()
+
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.ExtraResources = []string{
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Name }}-cdn\ndata:\n  origin: {{ .MainDomain }}\n",
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(getConfigMap, timeout).Should(Succeed())
+			Expect(cm.Data).To(HaveKeyWithValue("origin", wordpress.New(wp).MainDomain()))
+
+			// the keys removed from the manifest are removed from the object
+			Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+			wp.Spec.ExtraResources = []string{
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Name }}-cdn\nbinaryData:\n  logo: dGVzdA==\n",
+			}
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(func() map[string]string {
+				Expect(getConfigMap()).To(Succeed())
+
+				return cm.Data
+			}, timeout).Should(BeEmpty())
+			Expect(cm.BinaryData).To(HaveKey("logo"))
+			Expect(cm.OwnerReferences).To(HaveLen(1))
+			Expect(cm.OwnerReferences[0].Name).To(Equal(wp.Name))
+
+			Eventually(func() []wordpressv1alpha1.ResourceReference {
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				return wp.Status.Resources
+			}, timeout).Should(ContainElement(wordpressv1alpha1.ResourceReference{APIVersion: "v1", Kind: "ConfigMap", Name: cmKey.Name}))
+
+			wp.Spec.ExtraResources = nil
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(getConfigMap, timeout).ShouldNot(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Wordpress controller caching only the managed objects", func() {
+	var (
+		// channel for incoming reconcile requests
+		requests chan reconcile.Request
+		// stop channel for controller manager
+		stop context.CancelFunc
+		// controller k8s client, reading through the restricted cache
+		c client.Client
+	)
+
+	BeforeEach(func() {
+		var recFn reconcile.Reconciler
+
+		options.CacheManagedObjectsOnly = true
+
+		mgr, err := manager.New(cfg, manager.Options{
+			MetricsBindAddress: "0",
+			NewCache:           cache.BuilderWithOptions(cache.Options{SelectorsByObject: CacheSelectors()}),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		c = mgr.GetClient()
+
+		recFn, requests = SetupTestReconcile(newReconciler(mgr))
+		Expect(add(mgr, recFn)).To(Succeed())
+
+		stop = StartTestManager(mgr)
+	})
+
+	AfterEach(func() {
+		stop()
+		options.CacheManagedObjectsOnly = false
+	})
+
+	// nolint: errcheck
+	It("finds the objects it creates in the cache", func() {
+		name := fmt.Sprintf("wp-%d", rand.Int31())
+
+		wp := &wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes:    []wordpressv1alpha1.RouteSpec{{Domain: fmt.Sprintf("%s.example.com", name)}},
+				PHPConfig: map[string]string{"memory_limit": "256M"},
+				MediaGC:   &wordpressv1alpha1.MediaGCSpec{},
+				AdminUsers: []wordpressv1alpha1.AdminUserSpec{
+					{
+						Username: "admin",
+						Email:    "admin@example.com",
+						PasswordSecretRef: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: name},
+							Key:                  "ADMIN_PASSWORD",
+						},
+					},
+				},
+			},
+		}
+		Expect(c.Create(context.TODO(), wp)).To(Succeed())
+		defer c.Delete(context.TODO(), wp)
+
+		get := func(name string, obj client.Object) func() error {
+			return func() error {
+				// unblock the reconciliations
+				select {
+				case <-requests:
+				default:
+				}
+
+				return c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: wp.Namespace}, obj)
+			}
+		}
+
+		// the objects missing the managed-by label are not found in the restricted cache
+		Eventually(get(name+"-wp", &corev1.Secret{}), timeout).Should(Succeed())
+		Eventually(get(name, &appsv1.Deployment{}), timeout).Should(Succeed())
+		Eventually(get(name, &corev1.Service{}), timeout).Should(Succeed())
+		Eventually(get(name, &netv1.Ingress{}), timeout).Should(Succeed())
+		Eventually(get(name+"-php-config", &corev1.ConfigMap{}), timeout).Should(Succeed())
+		Eventually(get(name+"-media-gc", &batchv1beta1.CronJob{}), timeout).Should(Succeed())
+
+		// the admin user job
+		Eventually(func() []string {
+			jobs := &batchv1.JobList{}
+			Expect(c.List(context.TODO(), jobs, client.InNamespace(wp.Namespace))).To(Succeed())
+
+			var names []string
+			for _, job := range jobs.Items {
+				names = append(names, job.Name)
+			}
+
+			return names
+		}, timeout).Should(ContainElement(HavePrefix(name + "-")))
+
+		// the objects are updated in place, instead of being created over and over
+		Eventually(func() corev1.ConditionStatus {
+			Expect(get(name, wp)()).To(Succeed())
+
+			for _, cond := range wp.Status.Conditions {
+				if cond.Type == wordpressv1alpha1.ResourcesSyncedCondition {
+					return cond.Status
+				}
+			}
+
+			return corev1.ConditionUnknown
+		}, timeout).Should(Equal(corev1.ConditionTrue))
+	})
+
+	// nolint: errcheck
+	It("rolls the web pods when the envFrom sources change", func() {
+		name := fmt.Sprintf("wp-%d", rand.Int31())
+
+		// created by the user, so it's missing from the restricted cache
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-env", Namespace: "default"},
+			Data:       map[string]string{"FOO": "bar"},
+		}
+		Expect(c.Create(context.TODO(), cm)).To(Succeed())
+		defer c.Delete(context.TODO(), cm)
+
+		wp := &wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes: []wordpressv1alpha1.RouteSpec{{Domain: fmt.Sprintf("%s.example.com", name)}},
+				EnvFrom: []corev1.EnvFromSource{
+					{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: cm.Name}}},
+				},
+			},
+		}
+		Expect(c.Create(context.TODO(), wp)).To(Succeed())
+		defer c.Delete(context.TODO(), wp)
+
+		checksum := func() string {
+			// unblock the reconciliations
+			select {
+			case <-requests:
+			default:
+			}
+
+			deploy := &appsv1.Deployment{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: wp.Namespace}, deploy); err != nil {
+				return ""
+			}
+
+			return deploy.Spec.Template.Annotations[wordpress.EnvFromChecksumAnnotation]
+		}
+
+		Eventually(checksum, timeout).ShouldNot(BeEmpty())
+		previous := checksum()
+
+		cm.Data["FOO"] = "baz"
+		Expect(c.Update(context.TODO(), cm)).To(Succeed())
+
+		Eventually(checksum, timeout).ShouldNot(Equal(previous))
 	})
 })
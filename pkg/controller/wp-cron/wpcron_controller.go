@@ -43,9 +43,8 @@ import (
 )
 
 const (
-	controllerName      = "wp-cron-controller"
-	cronTriggerInterval = 30 * time.Second
-	cronTriggerTimeout  = 30 * time.Second
+	controllerName     = "wp-cron-controller"
+	cronTriggerTimeout = 30 * time.Second
 )
 
 var errHTTP = errors.New("HTTP error")
@@ -110,11 +109,15 @@ func (r *ReconcileWordpress) Reconcile(ctx context.Context, request reconcile.Re
 	r.scheme.Default(wp.Unwrap())
 	wp.SetDefaults()
 
+	if !wp.IsWPCronManaged() || wp.IsBlueprint() || !wp.InShard() {
+		return reconcile.Result{}, nil
+	}
+
 	log := r.Log.WithValues("key", request.NamespacedName)
 
 	requeue := reconcile.Result{
 		Requeue:      true,
-		RequeueAfter: cronTriggerInterval,
+		RequeueAfter: wp.Spec.WPCronInterval.Duration,
 	}
 
 	svcHostname := fmt.Sprintf("%s.%s.svc", wp.Name, wp.Namespace)
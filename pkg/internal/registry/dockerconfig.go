@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// legacyDockerHubHosts are the hosts docker login uses for Docker Hub.
+var legacyDockerHubHosts = map[string]bool{
+	"index.docker.io":      true,
+	"registry-1.docker.io": true,
+}
+
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// CredentialsFromDockerConfig parses the credentials from the contents of a
+// kubernetes.io/dockerconfigjson secret, keyed by registry host.
+func CredentialsFromDockerConfig(data []byte) (map[string]Credentials, error) {
+	config := dockerConfigJSON{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	creds := make(map[string]Credentials, len(config.Auths))
+
+	for server, entry := range config.Auths {
+		c := Credentials{Username: entry.Username, Password: entry.Password}
+
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, err
+			}
+
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				c.Username, c.Password = parts[0], parts[1]
+			}
+		}
+
+		creds[registryHost(server)] = c
+	}
+
+	return creds, nil
+}
+
+// registryHost returns the registry host of a docker config server entry,
+// which may be given as an URL, eg. https://index.docker.io/v1/
+func registryHost(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+
+	host = strings.SplitN(host, "/", 2)[0]
+
+	if legacyDockerHubHosts[host] {
+		return dockerHub
+	}
+
+	return host
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry implements a minimal client for the Docker Registry HTTP
+// API V2, used for resolving image tags to digests.
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	dockerHub         = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+var (
+	// ErrInvalidReference is returned when an image reference can't be parsed
+	ErrInvalidReference = errors.New("invalid image reference")
+	// ErrDigestNotFound is returned when the registry doesn't return the manifest digest
+	ErrDigestNotFound = errors.New("the registry didn't return the manifest digest")
+	// ErrUnexpectedStatus is returned when the registry responds with an unexpected status
+	ErrUnexpectedStatus = errors.New("unexpected registry response status")
+
+	errCredentialsRequired  = errors.New("the registry requires credentials")
+	errUnsupportedChallenge = errors.New("unsupported authentication challenge")
+	errInvalidChallenge     = errors.New("invalid authentication challenge")
+
+	challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Name is the image name, as it was given, without tag and digest
+	Name string
+	// Registry is the registry host
+	Registry string
+	// Repository is the repository path within the registry
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference in the [registry/]repository[:tag][@digest] form.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+	name := image
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if i == len(name)-1 {
+			return ref, fmt.Errorf("%w: %q", ErrInvalidReference, image)
+		}
+
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+
+	if name == "" {
+		return ref, fmt.Errorf("%w: %q", ErrInvalidReference, image)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	ref.Name = name
+	ref.Registry, ref.Repository = dockerHub, name
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, ref.Repository = host, name[i+1:]
+		}
+	}
+
+	if ref.Registry == dockerHub && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	return ref, nil
+}
+
+// WithDigest returns the image reference pinned to the given digest.
+func (r Reference) WithDigest(digest string) string {
+	if r.Tag == "" {
+		return fmt.Sprintf("%s@%s", r.Name, digest)
+	}
+
+	return fmt.Sprintf("%s:%s@%s", r.Name, r.Tag, digest)
+}
+
+// Credentials are used for authenticating against a registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Client resolves image tags to digests.
+type Client struct {
+	HTTPClient *http.Client
+	// Credentials maps registry hosts to the credentials used for them
+	Credentials map[string]Credentials
+	// Insecure makes the client talk plain HTTP to the registries
+	Insecure bool
+}
+
+// ResolveDigest returns the digest of the manifest the reference tag points to.
+func (c *Client) ResolveDigest(ctx context.Context, ref Reference) (string, error) {
+	if ref.Tag == "" {
+		return ref.Digest, nil
+	}
+
+	host := ref.Registry
+	if host == dockerHub {
+		host = dockerHubRegistry
+	}
+
+	scheme := "https"
+	if c.Insecure {
+		scheme = "http"
+	}
+
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, ref.Repository, ref.Tag)
+	creds, hasCreds := c.Credentials[ref.Registry]
+
+	resp, err := c.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		var auth string
+
+		if auth, err = c.authorize(ctx, resp.Header.Get("WWW-Authenticate"), creds, hasCreds); err != nil {
+			return "", err
+		}
+
+		if resp, err = c.headManifest(ctx, manifestURL, auth); err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: fetching the manifest of %s: %s", ErrUnexpectedStatus, ref.Name, resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", ErrDigestNotFound
+	}
+
+	return digest, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+func (c *Client) headManifest(ctx context.Context, manifestURL, auth string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// HEAD responses have no body, but it still must be closed
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// authorize returns the Authorization header value for the given challenge.
+func (c *Client) authorize(ctx context.Context, challenge string, creds Credentials, hasCreds bool) (string, error) {
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+
+	switch scheme {
+	case "basic":
+		if !hasCreds {
+			return "", errCredentialsRequired
+		}
+
+		return "Basic " + basicAuth(creds), nil
+	case "bearer":
+		token, err := c.fetchToken(ctx, challenge, creds, hasCreds)
+		if err != nil {
+			return "", err
+		}
+
+		return "Bearer " + token, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", errUnsupportedChallenge, challenge)
+}
+
+func (c *Client) fetchToken(ctx context.Context, challenge string, creds Credentials, hasCreds bool) (string, error) {
+	params := map[string]string{}
+	for _, m := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("%w: %q", errInvalidChallenge, challenge)
+	}
+
+	query := realm.Query()
+
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if hasCreds {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: fetching the registry token: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+
+	return body.AccessToken, nil
+}
+
+func basicAuth(creds Credentials) string {
+	return base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+const testDigest = "sha256:4355a46b19d348dc2f57c046f8ef63d4538ebb936000f3c9ee954a27460dd865"
+
+var _ = Describe("Registry client", func() {
+	DescribeTable("parsing image references",
+		func(image string, expected Reference) {
+			ref, err := ParseReference(image)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal(expected))
+		},
+		Entry("docker hub library image", "wordpress",
+			Reference{Name: "wordpress", Registry: "docker.io", Repository: "library/wordpress", Tag: "latest"}),
+		Entry("docker hub image with tag", "bitpoke/wordpress-runtime:5.8",
+			Reference{Name: "bitpoke/wordpress-runtime", Registry: "docker.io", Repository: "bitpoke/wordpress-runtime", Tag: "5.8"}),
+		Entry("image with registry", "gcr.io/project/site:v1",
+			Reference{Name: "gcr.io/project/site", Registry: "gcr.io", Repository: "project/site", Tag: "v1"}),
+		Entry("image with registry port", "localhost:5000/site",
+			Reference{Name: "localhost:5000/site", Registry: "localhost:5000", Repository: "site", Tag: "latest"}),
+		Entry("image with digest", "gcr.io/project/site@"+testDigest,
+			Reference{Name: "gcr.io/project/site", Registry: "gcr.io", Repository: "project/site", Digest: testDigest}),
+	)
+
+	It("rejects invalid references", func() {
+		_, err := ParseReference("wordpress:")
+		Expect(err).To(MatchError(ErrInvalidReference))
+	})
+
+	It("pins references to digests", func() {
+		ref, err := ParseReference("bitpoke/wordpress-runtime:5.8")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref.WithDigest(testDigest)).To(Equal("bitpoke/wordpress-runtime:5.8@" + testDigest))
+	})
+
+	It("parses docker config credentials", func() {
+		creds, err := CredentialsFromDockerConfig([]byte(`{"auths": {
+			"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+			"gcr.io": {"username": "_json_key", "password": "secret"}
+		}}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(creds).To(Equal(map[string]Credentials{
+			"docker.io": {Username: "user", Password: "pass"},
+			"gcr.io":    {Username: "_json_key", Password: "secret"},
+		}))
+	})
+
+	When("resolving digests", func() {
+		var (
+			server *httptest.Server
+			client *Client
+			ref    Reference
+		)
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				user, pass, ok := r.BasicAuth()
+				if !ok || user != "user" || pass != "pass" || r.URL.Query().Get("scope") != "repository:project/site:pull" {
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+
+				fmt.Fprint(w, `{"token": "the-token"}`)
+			})
+			mux.HandleFunc("/v2/project/site/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer the-token" {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+						`Bearer realm="http://%s/token",service="registry",scope="repository:project/site:pull"`, r.Host))
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+
+				Expect(r.Method).To(Equal(http.MethodHead))
+				Expect(r.Header.Get("Accept")).To(ContainSubstring("application/vnd.oci.image.index.v1+json"))
+				w.Header().Set("Docker-Content-Digest", testDigest)
+			})
+			server = httptest.NewServer(mux)
+
+			var err error
+			ref, err = ParseReference(strings.TrimPrefix(server.URL, "http://") + "/project/site:v1")
+			Expect(err).ToNot(HaveOccurred())
+
+			client = &Client{
+				Insecure:    true,
+				Credentials: map[string]Credentials{ref.Registry: {Username: "user", Password: "pass"}},
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("authenticates and returns the manifest digest", func() {
+			Expect(client.ResolveDigest(context.TODO(), ref)).To(Equal(testDigest))
+		})
+
+		It("fails without credentials", func() {
+			client.Credentials = nil
+			_, err := client.ResolveDigest(context.TODO(), ref)
+			Expect(err).To(MatchError(ContainSubstring("unexpected registry response status")))
+		})
+
+		It("fails for unknown tags", func() {
+			ref.Tag = "v2"
+			_, err := client.ResolveDigest(context.TODO(), ref)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+// AdminUserToken returns a hash of the user spec, identifying the job which
+// applies it.
+func AdminUserToken(user *wordpressv1alpha1.AdminUserSpec) string {
+	spec := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s", user.Username, user.Email, user.Role,
+		user.PasswordSecretRef.Name, user.PasswordSecretRef.Key, user.PasswordResetToken)
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(spec)))
+}
+
+// AdminUserStatus returns the last applied state of the given user or nil if
+// the user wasn't applied yet.
+func (wp *Wordpress) AdminUserStatus(username string) *wordpressv1alpha1.AdminUserStatus {
+	for i := range wp.Status.AdminUsers {
+		if wp.Status.AdminUsers[i].Username == username {
+			return &wp.Status.AdminUsers[i]
+		}
+	}
+
+	return nil
+}
+
+// PendingAdminUsers returns the users whose spec changed since they were last
+// applied.
+func (wp *Wordpress) PendingAdminUsers() []*wordpressv1alpha1.AdminUserSpec {
+	var pending []*wordpressv1alpha1.AdminUserSpec
+
+	for i := range wp.Spec.AdminUsers {
+		user := &wp.Spec.AdminUsers[i]
+
+		status := wp.AdminUserStatus(user.Username)
+		if status == nil || status.Token != AdminUserToken(user) {
+			pending = append(pending, user)
+		}
+	}
+
+	return pending
+}
+
+// NeedsAdminPasswordReset returns true if the user password must be reset,
+// as its reset token changed since the user was last applied.
+func (wp *Wordpress) NeedsAdminPasswordReset(user *wordpressv1alpha1.AdminUserSpec) bool {
+	if user.PasswordResetToken == "" {
+		return false
+	}
+
+	status := wp.AdminUserStatus(user.Username)
+
+	return status == nil || status.PasswordResetToken != user.PasswordResetToken
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// AlertRule is an alerting rule of the site PrometheusRule.
+type AlertRule struct {
+	Alert       string
+	Expr        string
+	For         string
+	Severity    string
+	Summary     string
+	Description string
+}
+
+// HasAlerts returns true if a PrometheusRule holding the site alerts is created.
+func (wp *Wordpress) HasAlerts() bool {
+	return wp.HasMetrics() && wp.Spec.Metrics.Alerts != nil
+}
+
+// AlertRules returns the alerting rules of the site. The 5xx rate and the
+// certificate expiry are computed using the ingress-nginx controller metrics
+// and the replicas using the kube-state-metrics ones.
+func (wp *Wordpress) AlertRules() []AlertRule {
+	alerts := wp.Spec.Metrics.Alerts
+	site := fmt.Sprintf("%s/%s", wp.Namespace, wp.Name)
+	svc := fmt.Sprintf(`namespace="%s", service="%s"`, wp.Namespace, wp.ComponentName(WordpressService))
+	deploy := fmt.Sprintf(`namespace="%s", deployment="%s"`, wp.Namespace, wp.ComponentName(WordpressDeployment))
+	ingress := fmt.Sprintf(`namespace="%s", ingress="%s"`, wp.Namespace, wp.ComponentName(WordpressIngress))
+	forDuration := alerts.For.Duration.String()
+
+	rules := []AlertRule{
+		{
+			Alert: "WordpressSiteDown",
+			Expr: fmt.Sprintf(`sum(kube_deployment_spec_replicas{%s}) > 0 and sum(kube_deployment_status_replicas_available{%s}) == 0`,
+				deploy, deploy),
+			For:         forDuration,
+			Severity:    "critical",
+			Summary:     "WordPress site is down",
+			Description: fmt.Sprintf("The site %s has no available web pods.", site),
+		},
+		{
+			Alert: "WordpressHighErrorRate",
+			Expr: fmt.Sprintf(`sum(rate(nginx_ingress_controller_requests{%s, status=~"5.."}[5m])) / sum(rate(nginx_ingress_controller_requests{%s}[5m])) * 100 > %d`,
+				ingress, ingress, *alerts.ErrorRatePercent),
+			For:         forDuration,
+			Severity:    "warning",
+			Summary:     "WordPress site has a high 5xx rate",
+			Description: fmt.Sprintf("More than %d%% of the requests to the site %s fail with 5xx errors.", *alerts.ErrorRatePercent, site),
+		},
+		{
+			Alert:       "WordpressPHPFPMSaturated",
+			Expr:        fmt.Sprintf(`max(phpfpm_listen_queue{%s}) > %d`, svc, *alerts.ListenQueueLength),
+			For:         forDuration,
+			Severity:    "warning",
+			Summary:     "WordPress php-fpm is saturated",
+			Description: fmt.Sprintf("More than %d requests are waiting for a php-fpm worker on the site %s.", *alerts.ListenQueueLength, site),
+		},
+	}
+
+	if hosts := wp.tlsHosts(); len(hosts) > 0 {
+		rules = append(rules, AlertRule{
+			Alert: "WordpressCertificateExpiring",
+			Expr: fmt.Sprintf(`min(nginx_ingress_controller_ssl_expire_time_seconds{host=~"%s"}) - time() < %d`,
+				hostsRegex(hosts), *alerts.CertificateExpiryDays*secondsPerDay),
+			For:         forDuration,
+			Severity:    "warning",
+			Summary:     "WordPress site certificate is expiring",
+			Description: fmt.Sprintf("The TLS certificate of the site %s expires in less than %d days.", site, *alerts.CertificateExpiryDays),
+		})
+	}
+
+	return rules
+}
+
+// tlsHosts returns the sorted hosts served over TLS.
+func (wp *Wordpress) tlsHosts() []string {
+	if len(wp.Spec.TLSSecretRef) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	hosts := []string{}
+
+	for _, route := range wp.Spec.Routes {
+		if !seen[route.Domain] {
+			seen[route.Domain] = true
+			hosts = append(hosts, route.Domain)
+		}
+	}
+
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// hostsRegex returns a PromQL regex matching the given hosts, with the
+// backslashes escaped for being used in a double-quoted string.
+func hostsRegex(hosts []string) string {
+	quoted := make([]string, len(hosts))
+	for i, host := range hosts {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(host), `\`, `\\`)
+	}
+
+	return strings.Join(quoted, "|")
+}
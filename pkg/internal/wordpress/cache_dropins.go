@@ -0,0 +1,185 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RedisObjectCache uses the Redis Object Cache plugin.
+	RedisObjectCache = "redis"
+	// MemcachedObjectCache uses the Memcached Object Cache plugin.
+	MemcachedObjectCache = "memcached"
+
+	// ObjectCacheDropinKey is the cache drop-ins ConfigMap key holding the object cache drop-in.
+	ObjectCacheDropinKey = "object-cache.php"
+	// AdvancedCacheDropinKey is the cache drop-ins ConfigMap key holding the page cache drop-in.
+	AdvancedCacheDropinKey = "advanced-cache.php"
+
+	cacheDropinsVolumeName         = "cache-dropins"
+	cacheDropinsChecksumAnnotation = "wordpress.presslabs.org/cacheDropinsChecksum"
+)
+
+// The drop-ins only load the ones shipped by the plugins, so they don't
+// need to be updated together with the plugins. If the plugin is missing,
+// WordPress falls back to the non-persistent object cache.
+const redisObjectCacheDropin = `<?php
+// Generated by the wordpress-operator. Do not edit.
+
+defined('WP_REDIS_HOST') || define('WP_REDIS_HOST', '%s');
+defined('WP_REDIS_PORT') || define('WP_REDIS_PORT', %s);
+
+if (file_exists(WP_CONTENT_DIR . '/plugins/redis-cache/includes/object-cache.php')) {
+    require_once WP_CONTENT_DIR . '/plugins/redis-cache/includes/object-cache.php';
+}
+`
+
+const memcachedObjectCacheDropin = `<?php
+// Generated by the wordpress-operator. Do not edit.
+
+global $memcached_servers;
+$memcached_servers = array('default' => array('%s:%s'));
+
+if (file_exists(WP_CONTENT_DIR . '/plugins/memcached/object-cache.php')) {
+    require_once WP_CONTENT_DIR . '/plugins/memcached/object-cache.php';
+}
+`
+
+const batcacheAdvancedCacheDropin = `<?php
+// Generated by the wordpress-operator. Do not edit.
+
+if (file_exists(WP_CONTENT_DIR . '/plugins/batcache/advanced-cache.php')) {
+    require_once WP_CONTENT_DIR . '/plugins/batcache/advanced-cache.php';
+}
+`
+
+var defaultObjectCachePorts = map[string]string{
+	RedisObjectCache:     "6379",
+	MemcachedObjectCache: "11211",
+}
+
+// HasObjectCache returns true if the object cache drop-ins are managed for the site.
+func (wp *Wordpress) HasObjectCache() bool {
+	return wp.Spec.Cache != nil && wp.Spec.Cache.ObjectCache != nil
+}
+
+// CacheDropins returns the cache drop-in files managed for the site, keyed by file name.
+func (wp *Wordpress) CacheDropins() map[string]string {
+	if !wp.HasObjectCache() {
+		return nil
+	}
+
+	cache := wp.Spec.Cache.ObjectCache
+
+	host, port, err := net.SplitHostPort(cache.Host)
+	if err != nil {
+		host, port = cache.Host, defaultObjectCachePorts[cache.Backend]
+	}
+
+	host = phpStringEscaper.Replace(host)
+	port = phpStringEscaper.Replace(port)
+
+	out := map[string]string{}
+
+	switch cache.Backend {
+	case MemcachedObjectCache:
+		out[ObjectCacheDropinKey] = fmt.Sprintf(memcachedObjectCacheDropin, host, port)
+	default:
+		out[ObjectCacheDropinKey] = fmt.Sprintf(redisObjectCacheDropin, host, port)
+	}
+
+	if cache.PageCache {
+		out[AdvancedCacheDropinKey] = batcacheAdvancedCacheDropin
+	}
+
+	return out
+}
+
+// cacheDropinsKeys returns the sorted names of the managed cache drop-ins.
+func (wp *Wordpress) cacheDropinsKeys() []string {
+	dropins := wp.CacheDropins()
+
+	keys := make([]string, 0, len(dropins))
+	for key := range dropins {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// cacheDropinsChecksum returns the checksum of the managed cache drop-ins.
+func (wp *Wordpress) cacheDropinsChecksum() string {
+	dropins := wp.CacheDropins()
+	hash := sha256.New()
+
+	for _, key := range wp.cacheDropinsKeys() {
+		fmt.Fprintf(hash, "%s\n%s", key, dropins[key])
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// cacheDropinsEnv enables loading the advanced-cache.php drop-in.
+func (wp *Wordpress) cacheDropinsEnv() []corev1.EnvVar {
+	if !wp.HasObjectCache() || !wp.Spec.Cache.ObjectCache.PageCache || hasEnv("WP_CACHE", wp.Spec.Env) {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "WP_CACHE",
+			Value: "true",
+		},
+	}
+}
+
+func (wp *Wordpress) cacheDropinsVolumeMounts() []corev1.VolumeMount {
+	out := []corev1.VolumeMount{}
+
+	for _, key := range wp.cacheDropinsKeys() {
+		out = append(out, corev1.VolumeMount{
+			MountPath: path.Join(wp.contentDir(), key),
+			Name:      cacheDropinsVolumeName,
+			ReadOnly:  true,
+			SubPath:   key,
+		})
+	}
+
+	return out
+}
+
+func (wp *Wordpress) cacheDropinsVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: cacheDropinsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: wp.ComponentName(WordpressCacheDropins),
+				},
+			},
+		},
+	}
+}
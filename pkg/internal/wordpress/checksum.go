@@ -0,0 +1,88 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretChecksumAnnotation holds the checksum of the site secret on the web pods.
+	SecretChecksumAnnotation = "wordpress.presslabs.org/secretChecksum"
+	// DBSecretChecksumAnnotation holds the checksum of the database secret on the web pods.
+	DBSecretChecksumAnnotation = "wordpress.presslabs.org/dbSecretChecksum"
+	// EnvFromChecksumAnnotation holds the checksum of the envFrom sources on the web pods.
+	EnvFromChecksumAnnotation = "wordpress.presslabs.org/envFromChecksum"
+)
+
+// SecretChecksum returns the checksum of the secret data.
+func SecretChecksum(secret *corev1.Secret) string {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+
+	for k, v := range secret.StringData {
+		data[k] = []byte(v)
+	}
+
+	return checksum(data)
+}
+
+// ConfigMapChecksum returns the checksum of the config map data.
+func ConfigMapChecksum(cm *corev1.ConfigMap) string {
+	data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+
+	for k, v := range cm.BinaryData {
+		data[k] = v
+	}
+
+	return checksum(data)
+}
+
+// Checksum combines multiple checksums into a single one.
+func Checksum(checksums ...string) string {
+	h := sha256.New()
+	for _, c := range checksums {
+		fmt.Fprintf(h, "%s\n", c)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func checksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\x00%s\x00", k, data[k])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
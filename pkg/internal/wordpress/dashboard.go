@@ -0,0 +1,163 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"crypto/sha1" // nolint: gosec
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	dashboardPanelWidth  = 12
+	dashboardPanelHeight = 8
+)
+
+type dashboardTarget struct {
+	legend string
+	query  string
+}
+
+type dashboardPanel struct {
+	title   string
+	unit    string
+	targets []dashboardTarget
+}
+
+// HasGrafanaDashboard returns true if a Grafana dashboard is generated for the site.
+func (wp *Wordpress) HasGrafanaDashboard() bool {
+	return wp.HasMetrics() && wp.Spec.Metrics.Dashboard != nil
+}
+
+// GrafanaDashboardKey returns the dashboard ConfigMap key holding the dashboard.
+func (wp *Wordpress) GrafanaDashboardKey() string {
+	return fmt.Sprintf("wordpress-%s-%s.json", wp.Namespace, wp.Name)
+}
+
+// GrafanaDashboard renders the Grafana dashboard of the site, showing the
+// traffic, the latency, the php-fpm saturation and the number of replicas.
+func (wp *Wordpress) GrafanaDashboard() string {
+	svc := fmt.Sprintf(`namespace="%s", service="%s"`, wp.Namespace, wp.ComponentName(WordpressService))
+	deploy := fmt.Sprintf(`namespace="%s", deployment="%s"`, wp.Namespace, wp.ComponentName(WordpressDeployment))
+
+	panels := []dashboardPanel{
+		{
+			title: "Requests",
+			unit:  "reqps",
+			targets: []dashboardTarget{
+				{legend: "requests", query: fmt.Sprintf(`sum(rate(nginx_http_requests_total{%s}[5m]))`, svc)},
+			},
+		},
+		{
+			title: "Connections",
+			unit:  "short",
+			targets: []dashboardTarget{
+				{legend: "{{ state }}", query: fmt.Sprintf(`sum by (state) (nginx_connections_current{%s})`, svc)},
+			},
+		},
+		{
+			title: "PHP request duration",
+			unit:  "µs",
+			targets: []dashboardTarget{
+				{legend: "average", query: fmt.Sprintf(`avg(phpfpm_process_request_duration{%s})`, svc)},
+				{legend: "max", query: fmt.Sprintf(`max(phpfpm_process_request_duration{%s})`, svc)},
+			},
+		},
+		{
+			title: "php-fpm saturation",
+			unit:  "short",
+			targets: []dashboardTarget{
+				{legend: "active processes", query: fmt.Sprintf(`sum(phpfpm_active_processes{%s})`, svc)},
+				{legend: "total processes", query: fmt.Sprintf(`sum(phpfpm_total_processes{%s})`, svc)},
+				{legend: "listen queue", query: fmt.Sprintf(`sum(phpfpm_listen_queue{%s})`, svc)},
+				{legend: "max children reached", query: fmt.Sprintf(`sum(rate(phpfpm_max_children_reached{%s}[5m]))`, svc)},
+			},
+		},
+		{
+			title: "Replicas",
+			unit:  "short",
+			targets: []dashboardTarget{
+				{legend: "desired", query: fmt.Sprintf(`sum(kube_deployment_spec_replicas{%s})`, deploy)},
+				{legend: "available", query: fmt.Sprintf(`sum(kube_deployment_status_replicas_available{%s})`, deploy)},
+			},
+		},
+	}
+
+	dashboard := map[string]interface{}{
+		"uid":           fmt.Sprintf("%x", sha1.Sum([]byte(wp.Namespace+"/"+wp.Name))), // nolint: gosec
+		"title":         fmt.Sprintf("WordPress / %s / %s", wp.Namespace, wp.Name),
+		"tags":          []string{"wordpress"},
+		"timezone":      "browser",
+		"schemaVersion": 27,
+		"refresh":       "30s",
+		"time": map[string]string{
+			"from": "now-6h",
+			"to":   "now",
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+			},
+		},
+		"panels": renderDashboardPanels(panels),
+	}
+
+	// nolint: errchkjson
+	out, _ := json.MarshalIndent(dashboard, "", "  ")
+
+	return string(out)
+}
+
+func renderDashboardPanels(panels []dashboardPanel) []interface{} {
+	out := make([]interface{}, 0, len(panels))
+
+	for i, panel := range panels {
+		targets := []interface{}{}
+		for j, target := range panel.targets {
+			targets = append(targets, map[string]interface{}{
+				"expr":         target.query,
+				"legendFormat": target.legend,
+				"refId":        string(rune('A' + j)),
+			})
+		}
+
+		out = append(out, map[string]interface{}{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      panel.title,
+			"datasource": "$datasource",
+			"gridPos": map[string]int{
+				"x": (i % 2) * dashboardPanelWidth,
+				"y": (i / 2) * dashboardPanelHeight,
+				"w": dashboardPanelWidth,
+				"h": dashboardPanelHeight,
+			},
+			"fieldConfig": map[string]interface{}{
+				"defaults": map[string]string{
+					"unit": panel.unit,
+				},
+			},
+			"targets": targets,
+		})
+	}
+
+	return out
+}
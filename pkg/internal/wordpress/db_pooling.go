@@ -0,0 +1,126 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ProxySQLPort is the port on which the connection pooling sidecar accepts the database connections.
+	ProxySQLPort = 6033
+
+	proxySQLContainerName  = "proxysql"
+	proxySQLDataVolumeName = "proxysql-data"
+	proxySQLDataMountPath  = "/var/lib/proxysql"
+)
+
+// The ProxySQL configuration can't reference environment variables, so it
+// gets rendered at startup from the same database credentials the site uses.
+// The monitoring is disabled, since it requires a dedicated database user.
+const proxySQLScript = `set -e
+host="${DB_HOST%%%%:*}"
+port="${DB_HOST##*:}"
+if [ "${port}" = "${DB_HOST}" ]; then port=3306; fi
+escape() { printf '%%s' "$1" | sed 's/[\\"]/\\&/g'; }
+
+cat > /tmp/proxysql.cnf <<EOF
+datadir="%[1]s"
+admin_variables={
+    admin_credentials="admin:$(head -c 16 /dev/urandom | od -An -tx1 | tr -d ' \n')"
+    mysql_ifaces="127.0.0.1:6032"
+}
+mysql_variables={
+    interfaces="127.0.0.1:%[2]d"
+    max_connections=%[3]d
+    monitor_enabled=false
+    server_version="8.0"
+}
+mysql_servers=(
+    { address="${host}", port=${port}, hostgroup=0, max_connections=%[4]d }
+)
+mysql_users=(
+    { username="$(escape "${DB_USER}")", password="$(escape "${DB_PASSWORD}")", default_hostgroup=0 }
+)
+EOF
+
+exec proxysql -f --initial -c /tmp/proxysql.cnf
+`
+
+// HasDBPooling returns true if the web pods connect to the database through
+// the connection pooling sidecar.
+func (wp *Wordpress) HasDBPooling() bool {
+	return wp.Spec.Database != nil && wp.Spec.Database.Pooling != nil && wp.Spec.Database.Pooling.Enabled
+}
+
+func (wp *Wordpress) dbPoolingContainers() []corev1.Container {
+	if !wp.HasDBPooling() {
+		return nil
+	}
+
+	pooling := wp.Spec.Database.Pooling
+	script := fmt.Sprintf(proxySQLScript, proxySQLDataMountPath, ProxySQLPort, *pooling.MaxClientConnections, *pooling.MaxConnections)
+
+	return []corev1.Container{
+		{
+			Name:      proxySQLContainerName,
+			Image:     pooling.Image,
+			Command:   []string{"/bin/sh", "-c", script},
+			Env:       wp.env(),
+			EnvFrom:   wp.envFrom(),
+			Resources: pooling.Resources,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      proxySQLDataVolumeName,
+					MountPath: proxySQLDataMountPath,
+				},
+			},
+		},
+	}
+}
+
+// dbPoolingEnv points the site to the connection pooling sidecar. It must
+// come after the other environment variables, as it overrides DB_HOST.
+func (wp *Wordpress) dbPoolingEnv() []corev1.EnvVar {
+	if !wp.HasDBPooling() {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "DB_HOST",
+			Value: fmt.Sprintf("127.0.0.1:%d", ProxySQLPort),
+		},
+	}
+}
+
+func (wp *Wordpress) dbPoolingVolumes() []corev1.Volume {
+	if !wp.HasDBPooling() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: proxySQLDataVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
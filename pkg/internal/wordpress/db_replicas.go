@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DBConfigKey is the db-config ConfigMap key holding the HyperDB/LudicrousDB configuration.
+	DBConfigKey = "db-config.php"
+
+	dbConfigVolumeName         = "db-config"
+	dbConfigChecksumAnnotation = "wordpress.presslabs.org/dbConfigChecksum"
+)
+
+// The primary serves the reads only when no replica is available, as it has
+// a lower read priority. The replicas are skipped for a while when they
+// don't answer within the timeout.
+const dbConfigTpl = `<?php
+// Generated by the wordpress-operator. Do not edit.
+
+$wpdb->add_database(array(
+    'host'     => DB_HOST,
+    'user'     => DB_USER,
+    'password' => DB_PASSWORD,
+    'name'     => DB_NAME,
+    'write'    => 1,
+    'read'     => 2,
+));
+{{ range .replicas }}
+$wpdb->add_database(array(
+    'host'     => '{{ . }}',
+    'user'     => DB_USER,
+    'password' => DB_PASSWORD,
+    'name'     => DB_NAME,
+    'write'    => 0,
+    'read'     => 1,
+    'timeout'  => 0.2,
+));
+{{ end -}}
+`
+
+var (
+	dbConfigTemplate = template.Must(template.New("").Parse(dbConfigTpl))
+
+	phpStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+)
+
+// HasReadReplicas returns true if the read queries are sent to database replicas.
+func (wp *Wordpress) HasReadReplicas() bool {
+	return wp.Spec.Database != nil && wp.Spec.Database.ReadReplicas != nil && len(wp.readReplicaHosts()) > 0
+}
+
+// DBConfig returns the HyperDB/LudicrousDB configuration of the site.
+func (wp *Wordpress) DBConfig() string {
+	replicas := []string{}
+	for _, host := range wp.readReplicaHosts() {
+		replicas = append(replicas, phpStringEscaper.Replace(host))
+	}
+
+	var out bytes.Buffer
+
+	// nolint: errcheck
+	dbConfigTemplate.Execute(&out, map[string]interface{}{
+		"replicas": replicas,
+	})
+
+	return out.String()
+}
+
+func (wp *Wordpress) readReplicaHosts() []string {
+	replicas := wp.Spec.Database.ReadReplicas
+	hosts := append([]string{}, replicas.Hosts...)
+
+	if replicas.ServiceRef != nil {
+		hosts = append(hosts, fmt.Sprintf("%s.%s.svc:%d", replicas.ServiceRef.Name, wp.Namespace, replicas.ServiceRef.Port))
+	}
+
+	return hosts
+}
+
+// contentDir returns the path of the WP_CONTENT_DIR within the pods.
+func (wp *Wordpress) contentDir() string {
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.MountPath != "" {
+		return wp.Spec.CodeVolumeSpec.MountPath
+	}
+
+	return defaultCodeMountPath
+}
+
+func (wp *Wordpress) dbConfigVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		MountPath: path.Join(wp.contentDir(), DBConfigKey),
+		Name:      dbConfigVolumeName,
+		ReadOnly:  true,
+		SubPath:   DBConfigKey,
+	}
+}
+
+func (wp *Wordpress) dbConfigVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: dbConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: wp.ComponentName(WordpressDBConfig),
+				},
+			},
+		},
+	}
+}
@@ -18,11 +18,17 @@ package wordpress
 
 import (
 	"path"
+	"regexp"
+	"strings"
+	"time"
 
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/registry"
 )
 
 const (
@@ -44,7 +50,62 @@ const (
 	knativeInternalMountPath = "/var/knative-internal"
 )
 
-var varLogSizeLimit = resource.MustParse("1Gi")
+const (
+	defaultAppArmorProfile = "runtime/default"
+
+	defaultWPCronInterval = 30 * time.Second
+	minWPCronInterval     = 10 * time.Second
+
+	defaultVPAUpdateMode   = "Off"
+	defaultKEDAMinReplicas = int32(1)
+
+	defaultSecretStoreKind               = "SecretStore"
+	defaultExternalSecretRefreshInterval = time.Hour
+
+	defaultImageCheckInterval = time.Hour
+
+	defaultFastCGICacheTTL = time.Minute
+
+	defaultServiceMonitorInterval = 30 * time.Second
+
+	defaultAlertsFor             = 5 * time.Minute
+	defaultErrorRatePercent      = int32(5)
+	defaultListenQueueLength     = int32(10)
+	defaultCertificateExpiryDays = int32(14)
+
+	defaultMediaGCSchedule = "0 3 * * 0"
+
+	defaultDBMaintenanceSchedule = "0 4 * * 0"
+	defaultJobsHistoryLimit      = int32(1)
+
+	defaultDBPort = int32(3306)
+
+	defaultSiteHealthInterval = time.Hour
+
+	defaultAdminUserRole = "administrator"
+
+	defaultStickySessionsCookieName     = "wp-route"
+	defaultStickySessionsTimeoutSeconds = int32(10800)
+
+	defaultDBPoolingMaxConnections       = int32(10)
+	defaultDBPoolingMaxClientConnections = int32(1024)
+)
+
+var (
+	varLogSizeLimit            = resource.MustParse("1Gi")
+	defaultFastCGICacheMaxSize = resource.MustParse("256Mi")
+)
+
+// defaultSiteHealthTests are the Site Health tests exposed through the REST API.
+var defaultSiteHealthTests = []string{
+	"background-updates",
+	"loopback-requests",
+	"https-status",
+	"dotorg-communication",
+	"authorization-header",
+}
+
+var phpTagSuffixRegex = regexp.MustCompile(`-php\d+$`)
 
 // SetDefaults sets Wordpress field defaults.
 func (wp *Wordpress) SetDefaults() {
@@ -52,6 +113,10 @@ func (wp *Wordpress) SetDefaults() {
 		wp.Spec.Image = options.WordpressRuntimeImage
 	}
 
+	if len(wp.Spec.PHPVersion) > 0 {
+		wp.Spec.Image = imageWithPHPVersion(wp.Spec.Image, wp.Spec.PHPVersion)
+	}
+
 	if len(wp.Spec.ImagePullPolicy) == 0 {
 		wp.Spec.ImagePullPolicy = corev1.PullAlways
 	}
@@ -79,4 +144,274 @@ func (wp *Wordpress) SetDefaults() {
 	if wp.Spec.WordpressPathPrefix == "" {
 		wp.Spec.WordpressPathPrefix = "/wp"
 	}
+
+	if wp.Spec.RevisionHistoryLimit == nil {
+		revisionHistoryLimit := options.RevisionHistoryLimit
+		wp.Spec.RevisionHistoryLimit = &revisionHistoryLimit
+	}
+
+	if wp.Spec.MinReadySeconds == nil {
+		minReadySeconds := options.MinReadySeconds
+		wp.Spec.MinReadySeconds = &minReadySeconds
+	}
+
+	if wp.Spec.ProgressDeadlineSeconds == nil {
+		progressDeadlineSeconds := options.ProgressDeadlineSeconds
+		wp.Spec.ProgressDeadlineSeconds = &progressDeadlineSeconds
+	}
+
+	if wp.Spec.TerminationGracePeriodSeconds == nil {
+		terminationGracePeriodSeconds := options.TerminationGracePeriodSeconds
+		wp.Spec.TerminationGracePeriodSeconds = &terminationGracePeriodSeconds
+	}
+
+	if wp.Spec.DNSPolicy == "" {
+		wp.Spec.DNSPolicy = corev1.DNSClusterFirst
+	}
+
+	if wp.Spec.ManagedWPCron == nil {
+		managed := true
+		wp.Spec.ManagedWPCron = &managed
+	}
+
+	if wp.Spec.WPCronInterval == nil {
+		wp.Spec.WPCronInterval = &metav1.Duration{Duration: defaultWPCronInterval}
+	}
+
+	// shorter intervals would make the operator hammer the sites
+	if wp.Spec.WPCronInterval.Duration < minWPCronInterval {
+		wp.Spec.WPCronInterval = &metav1.Duration{Duration: minWPCronInterval}
+	}
+
+	if wp.Spec.SeccompProfile == nil {
+		wp.Spec.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		}
+	}
+
+	if wp.Spec.AppArmorProfile == "" {
+		wp.Spec.AppArmorProfile = defaultAppArmorProfile
+	}
+
+	if wp.HasVerticalAutoscaling() && wp.Spec.Autoscaling.Vertical.UpdateMode == "" {
+		wp.Spec.Autoscaling.Vertical.UpdateMode = defaultVPAUpdateMode
+	}
+
+	if wp.HasKEDAAutoscaling() && wp.Spec.Autoscaling.KEDA.MinReplicas == nil {
+		minReplicas := defaultKEDAMinReplicas
+		wp.Spec.Autoscaling.KEDA.MinReplicas = &minReplicas
+	}
+
+	if wp.PinsImageDigest() && wp.Spec.ImagePolicy.CheckInterval == nil {
+		wp.Spec.ImagePolicy.CheckInterval = &metav1.Duration{Duration: defaultImageCheckInterval}
+	}
+
+	if wp.HasFastCGICache() && wp.Spec.Cache.FastCGI.MaxSize == nil {
+		maxSize := defaultFastCGICacheMaxSize.DeepCopy()
+		wp.Spec.Cache.FastCGI.MaxSize = &maxSize
+	}
+
+	if wp.HasFastCGICache() && wp.Spec.Cache.FastCGI.TTL == nil {
+		wp.Spec.Cache.FastCGI.TTL = &metav1.Duration{Duration: defaultFastCGICacheTTL}
+	}
+
+	if wp.Spec.Logging != nil && wp.Spec.Logging.Format == "" {
+		wp.Spec.Logging.Format = CombinedLogFormat
+	}
+
+	if wp.HasLogShippingSidecar() {
+		sidecar := wp.Spec.Logging.Sidecar
+
+		if sidecar.Type == "" {
+			sidecar.Type = FluentBitLogShipper
+		}
+
+		if sidecar.Image == "" && sidecar.Type == FluentBitLogShipper {
+			sidecar.Image = options.FluentBitImage
+		}
+
+		if sidecar.Image == "" && sidecar.Type == VectorLogShipper {
+			sidecar.Image = options.VectorImage
+		}
+	}
+
+	if wp.HasServiceMonitor() && wp.Spec.Metrics.ServiceMonitor.Interval == nil {
+		wp.Spec.Metrics.ServiceMonitor.Interval = &metav1.Duration{Duration: defaultServiceMonitorInterval}
+	}
+
+	if wp.HasGrafanaDashboard() && len(wp.Spec.Metrics.Dashboard.Labels) == 0 {
+		wp.Spec.Metrics.Dashboard.Labels = map[string]string{"grafana_dashboard": "1"}
+	}
+
+	if wp.HasAlerts() {
+		wp.setAlertsDefaults()
+	}
+
+	if wp.HasMediaGC() {
+		wp.setMediaGCDefaults()
+	}
+
+	if wp.HasDBMaintenance() {
+		wp.setDBMaintenanceDefaults()
+	}
+
+	if wp.HasDBPooling() {
+		wp.setDBPoolingDefaults()
+	}
+
+	if wp.HasSFTP() && wp.Spec.DeveloperAccess.SFTP.Image == "" {
+		wp.Spec.DeveloperAccess.SFTP.Image = options.SFTPImage
+	}
+
+	if wp.HasSFTP() && wp.Spec.DeveloperAccess.SFTP.ServiceType == "" {
+		wp.Spec.DeveloperAccess.SFTP.ServiceType = corev1.ServiceTypeClusterIP
+	}
+
+	if wp.HasWebDAV() && wp.Spec.DeveloperAccess.WebDAV.Image == "" {
+		wp.Spec.DeveloperAccess.WebDAV.Image = options.WebDAVImage
+	}
+
+	if wp.Spec.SiteHealth != nil && wp.Spec.SiteHealth.Interval == nil {
+		wp.Spec.SiteHealth.Interval = &metav1.Duration{Duration: defaultSiteHealthInterval}
+	}
+
+	if wp.Spec.SiteHealth != nil && len(wp.Spec.SiteHealth.Tests) == 0 {
+		wp.Spec.SiteHealth.Tests = append([]string{}, defaultSiteHealthTests...)
+	}
+
+	for i := range wp.Spec.AdminUsers {
+		if wp.Spec.AdminUsers[i].Role == "" {
+			wp.Spec.AdminUsers[i].Role = defaultAdminUserRole
+		}
+	}
+
+	if wp.HasStickySessions() {
+		sticky := wp.Spec.Routing.StickySessions
+
+		if sticky.CookieName == "" {
+			sticky.CookieName = defaultStickySessionsCookieName
+		}
+
+		if sticky.TimeoutSeconds == nil {
+			timeout := defaultStickySessionsTimeoutSeconds
+			sticky.TimeoutSeconds = &timeout
+		}
+	}
+
+	if wp.Spec.Database != nil && wp.Spec.Database.ReadReplicas != nil {
+		ref := wp.Spec.Database.ReadReplicas.ServiceRef
+		if ref != nil && ref.Port == 0 {
+			ref.Port = defaultDBPort
+		}
+	}
+
+	if wp.HasExternalDatabaseSecret() {
+		ref := wp.Spec.Database.ExternalSecretRef
+
+		if ref.StoreKind == "" {
+			ref.StoreKind = defaultSecretStoreKind
+		}
+
+		if ref.RefreshInterval == nil {
+			ref.RefreshInterval = &metav1.Duration{Duration: defaultExternalSecretRefreshInterval}
+		}
+	}
+}
+
+func (wp *Wordpress) setAlertsDefaults() {
+	alerts := wp.Spec.Metrics.Alerts
+
+	if alerts.For == nil {
+		alerts.For = &metav1.Duration{Duration: defaultAlertsFor}
+	}
+
+	if alerts.ErrorRatePercent == nil {
+		errorRate := defaultErrorRatePercent
+		alerts.ErrorRatePercent = &errorRate
+	}
+
+	if alerts.ListenQueueLength == nil {
+		listenQueue := defaultListenQueueLength
+		alerts.ListenQueueLength = &listenQueue
+	}
+
+	if alerts.CertificateExpiryDays == nil {
+		expiryDays := defaultCertificateExpiryDays
+		alerts.CertificateExpiryDays = &expiryDays
+	}
+}
+
+func (wp *Wordpress) setMediaGCDefaults() {
+	gc := wp.Spec.MediaGC
+
+	if gc.Schedule == "" {
+		gc.Schedule = defaultMediaGCSchedule
+	}
+
+	if gc.ConcurrencyPolicy == "" {
+		gc.ConcurrencyPolicy = batchv1beta1.ForbidConcurrent
+	}
+
+	if gc.SuccessfulJobsHistoryLimit == nil {
+		limit := defaultJobsHistoryLimit
+		gc.SuccessfulJobsHistoryLimit = &limit
+	}
+
+	if gc.FailedJobsHistoryLimit == nil {
+		limit := defaultJobsHistoryLimit
+		gc.FailedJobsHistoryLimit = &limit
+	}
+}
+
+func (wp *Wordpress) setDBMaintenanceDefaults() {
+	maintenance := wp.Spec.DBMaintenance
+
+	if maintenance.Schedule == "" {
+		maintenance.Schedule = defaultDBMaintenanceSchedule
+	}
+
+	if maintenance.ConcurrencyPolicy == "" {
+		maintenance.ConcurrencyPolicy = batchv1beta1.ForbidConcurrent
+	}
+
+	if maintenance.SuccessfulJobsHistoryLimit == nil {
+		limit := defaultJobsHistoryLimit
+		maintenance.SuccessfulJobsHistoryLimit = &limit
+	}
+
+	if maintenance.FailedJobsHistoryLimit == nil {
+		limit := defaultJobsHistoryLimit
+		maintenance.FailedJobsHistoryLimit = &limit
+	}
+}
+
+func (wp *Wordpress) setDBPoolingDefaults() {
+	pooling := wp.Spec.Database.Pooling
+
+	if pooling.Image == "" {
+		pooling.Image = options.ProxySQLImage
+	}
+
+	if pooling.MaxConnections == nil {
+		maxConnections := defaultDBPoolingMaxConnections
+		pooling.MaxConnections = &maxConnections
+	}
+
+	if pooling.MaxClientConnections == nil {
+		maxClientConnections := defaultDBPoolingMaxClientConnections
+		pooling.MaxClientConnections = &maxClientConnections
+	}
+}
+
+// imageWithPHPVersion returns the runtime image tag built for the given PHP
+// version. Images pinned to a digest are left untouched.
+func imageWithPHPVersion(image, version string) string {
+	ref, err := registry.ParseReference(image)
+	if err != nil || len(ref.Digest) > 0 {
+		return image
+	}
+
+	tag := phpTagSuffixRegex.ReplaceAllString(ref.Tag, "")
+
+	return ref.Name + ":" + tag + "-php" + strings.ReplaceAll(version, ".", "")
 }
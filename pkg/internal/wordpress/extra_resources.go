@@ -0,0 +1,111 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+var (
+	// ErrInvalidExtraResource is returned for the spec.extraResources manifests which can't be rendered.
+	ErrInvalidExtraResource = errors.New("invalid extra resource")
+
+	errExtraResourceIdentity  = errors.New("apiVersion, kind and metadata.name are required")
+	errExtraResourceNamespace = errors.New("the object must be in the site namespace")
+	errExtraResourceKind      = errors.New("the kind is not allowed by the operator")
+)
+
+// ExtraResources renders the spec.extraResources manifests. The invalid ones
+// are skipped and reported through the returned error.
+func (wp *Wordpress) ExtraResources() ([]*unstructured.Unstructured, error) {
+	objs := make([]*unstructured.Unstructured, 0, len(wp.Spec.ExtraResources))
+
+	var errs []error
+
+	for i, manifest := range wp.Spec.ExtraResources {
+		obj, err := wp.renderExtraResource(manifest)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: extraResources[%d]: %s", ErrInvalidExtraResource, i, err.Error()))
+
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, utilerrors.NewAggregate(errs)
+}
+
+func (wp *Wordpress) renderExtraResource(manifest string) (*unstructured.Unstructured, error) {
+	tmpl, err := template.New("extraResource").Option("missingkey=error").Parse(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &bytes.Buffer{}
+	if err = tmpl.Execute(rendered, wp); err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.ToJSON(rendered.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err = obj.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+
+	if obj.GetAPIVersion() == "" || obj.GetName() == "" {
+		return nil, errExtraResourceIdentity
+	}
+
+	if gk := obj.GroupVersionKind().GroupKind(); !extraResourceKindAllowed(gk) {
+		return nil, fmt.Errorf("%w: %s", errExtraResourceKind, gk)
+	}
+
+	if ns := obj.GetNamespace(); ns != "" && ns != wp.Namespace {
+		return nil, errExtraResourceNamespace
+	}
+
+	obj.SetNamespace(wp.Namespace)
+
+	return obj, nil
+}
+
+// extraResourceKindAllowed returns true if the kind is allowed through the
+// --extra-resource-kinds flag.
+func extraResourceKindAllowed(gk schema.GroupKind) bool {
+	for _, kind := range options.ExtraResourceKinds {
+		if schema.ParseGroupKind(kind) == gk {
+			return true
+		}
+	}
+
+	return false
+}
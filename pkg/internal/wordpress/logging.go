@@ -0,0 +1,263 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"sort"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// FluentBitLogShipper ships the logs using fluent-bit.
+	FluentBitLogShipper = "fluent-bit"
+	// VectorLogShipper ships the logs using vector.
+	VectorLogShipper = "vector"
+
+	logShippingContainerName        = "log-shipper"
+	logShippingConfigVolumeName     = "logging-config"
+	logShippingConfigMountPath      = "/etc/log-shipper"
+	logShippingDataVolumeName       = "log-shipper-data"
+	logShippingDataMountPath        = "/var/lib/log-shipper"
+	loggingConfigChecksumAnnotation = "wordpress.presslabs.org/loggingConfigChecksum"
+
+	fluentBitConfigKey = "fluent-bit.conf"
+	vectorConfigKey    = "vector.toml"
+)
+
+const fluentBitConfigTpl = `[SERVICE]
+    Flush     1
+    Log_Level warn
+
+[INPUT]
+    Name             tail
+    Path             {{ .logsDir }}/*.log,{{ .logsDir }}/*/*.log
+    Path_Key         file
+    Tag              wordpress.*
+    DB               {{ .dataDir }}/tail.db
+    Skip_Long_Lines  On
+    Refresh_Interval 5
+
+[FILTER]
+    Name   record_modifier
+    Match  *
+    Record site {{ .site }}
+    Record namespace {{ .namespace }}
+    Record pod ${POD_NAME}
+
+[OUTPUT]
+    Name   http
+    Match  *
+    Host   {{ .host }}
+    Port   {{ .port }}
+    URI    {{ .uri }}
+    Format json_lines
+    tls    {{ if .tls }}On{{ else }}Off{{ end }}
+{{- range .headers }}
+    Header {{ .Name }} {{ .Value }}
+{{- end }}
+`
+
+const vectorConfigTpl = `data_dir = "{{ .dataDir }}"
+
+[sources.logs]
+type = "file"
+include = ["{{ .logsDir }}/*.log", "{{ .logsDir }}/*/*.log"]
+
+[transforms.site]
+type = "remap"
+inputs = ["logs"]
+source = '''
+.site = "{{ .site }}"
+.namespace = "{{ .namespace }}"
+.pod = get_env_var!("POD_NAME")
+'''
+
+[sinks.endpoint]
+type = "http"
+inputs = ["site"]
+uri = {{ printf "%q" .endpoint }}
+encoding.codec = "ndjson"
+{{- if .headers }}
+
+[sinks.endpoint.request.headers]
+{{- range .headers }}
+{{ printf "%q" .Name }} = {{ printf "%q" .Value }}
+{{- end }}
+{{- end }}
+`
+
+var (
+	fluentBitConfigTemplate = template.Must(template.New("").Parse(fluentBitConfigTpl))
+	vectorConfigTemplate    = template.Must(template.New("").Parse(vectorConfigTpl))
+)
+
+type logShippingHeader struct {
+	Name  string
+	Value string
+}
+
+// HasLogShippingSidecar returns true if a log shipping sidecar is injected into the web pods.
+func (wp *Wordpress) HasLogShippingSidecar() bool {
+	return wp.Spec.Logging != nil && wp.Spec.Logging.Sidecar != nil
+}
+
+// LoggingConfig returns the log shipper configuration files, keyed by file name.
+func (wp *Wordpress) LoggingConfig() map[string]string {
+	if !wp.HasLogShippingSidecar() {
+		return nil
+	}
+
+	return map[string]string{
+		wp.logShippingConfigKey(): wp.logShippingConfig(),
+	}
+}
+
+func (wp *Wordpress) logShippingConfigKey() string {
+	if wp.Spec.Logging.Sidecar.Type == VectorLogShipper {
+		return vectorConfigKey
+	}
+
+	return fluentBitConfigKey
+}
+
+func (wp *Wordpress) logShippingConfig() string {
+	sidecar := wp.Spec.Logging.Sidecar
+
+	headers := make([]logShippingHeader, 0, len(sidecar.Headers))
+	for name, value := range sidecar.Headers {
+		headers = append(headers, logShippingHeader{Name: name, Value: value})
+	}
+
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+
+	data := map[string]interface{}{
+		"logsDir":   knativeVarLogMountPath,
+		"dataDir":   logShippingDataMountPath,
+		"site":      wp.Name,
+		"namespace": wp.Namespace,
+		"endpoint":  sidecar.Endpoint,
+		"headers":   headers,
+	}
+
+	// fluent-bit needs the endpoint split into its components
+	if u, err := url.Parse(sidecar.Endpoint); err == nil {
+		data["tls"] = u.Scheme == "https"
+		data["host"] = u.Hostname()
+		data["uri"] = u.RequestURI()
+
+		switch {
+		case u.Port() != "":
+			data["port"] = u.Port()
+		case u.Scheme == "https":
+			data["port"] = "443"
+		default:
+			data["port"] = "80"
+		}
+	}
+
+	tpl := fluentBitConfigTemplate
+	if sidecar.Type == VectorLogShipper {
+		tpl = vectorConfigTemplate
+	}
+
+	var out bytes.Buffer
+
+	// nolint: errcheck
+	tpl.Execute(&out, data)
+
+	return out.String()
+}
+
+func (wp *Wordpress) logShippingContainers() []corev1.Container {
+	if !wp.HasLogShippingSidecar() {
+		return nil
+	}
+
+	sidecar := wp.Spec.Logging.Sidecar
+	configPath := path.Join(logShippingConfigMountPath, wp.logShippingConfigKey())
+
+	args := []string{"--config", configPath}
+	if sidecar.Type != VectorLogShipper {
+		args = []string{"-c", configPath}
+	}
+
+	return []corev1.Container{
+		{
+			Name:    logShippingContainerName,
+			Image:   sidecar.Image,
+			Args:    args,
+			EnvFrom: sidecar.EnvFrom,
+			Env: []corev1.EnvVar{
+				{
+					Name: "POD_NAME",
+					ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.name",
+						},
+					},
+				},
+			},
+			Resources: sidecar.Resources,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      knativeVarLogVolume,
+					MountPath: knativeVarLogMountPath,
+					ReadOnly:  true,
+				},
+				{
+					Name:      logShippingConfigVolumeName,
+					MountPath: logShippingConfigMountPath,
+					ReadOnly:  true,
+				},
+				{
+					Name:      logShippingDataVolumeName,
+					MountPath: logShippingDataMountPath,
+				},
+			},
+		},
+	}
+}
+
+func (wp *Wordpress) logShippingVolumes() []corev1.Volume {
+	if !wp.HasLogShippingSidecar() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: logShippingConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.ComponentName(WordpressLoggingConfig),
+					},
+				},
+			},
+		},
+		{
+			Name: logShippingDataVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
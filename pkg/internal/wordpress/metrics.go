@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// NginxExporterPort is the port on which the nginx metrics exporter sidecar serves the metrics.
+	NginxExporterPort = 9113
+	// PHPFPMExporterPort is the port on which the php-fpm metrics exporter sidecar serves the metrics.
+	PHPFPMExporterPort = 9253
+
+	// NginxExporterPortName is the name of the nginx metrics exporter port.
+	NginxExporterPortName = "nginx-metrics"
+	// PHPFPMExporterPortName is the name of the php-fpm metrics exporter port.
+	PHPFPMExporterPortName = "php-fpm-metrics"
+
+	// NginxStatusConfigKey is the nginx-config ConfigMap key holding the stub status server configuration.
+	NginxStatusConfigKey = "status.conf"
+
+	nginxStatusPort = 8082
+)
+
+// The stub status is served by a dedicated server, listening only on the
+// loopback interface, as the runtime image server block can't be extended.
+var nginxStatusConfig = fmt.Sprintf(`server {
+    listen 127.0.0.1:%d;
+
+    location = /stub_status {
+        stub_status;
+        access_log off;
+    }
+}
+`, nginxStatusPort)
+
+// HasMetrics returns true if the metrics exporter sidecars are injected into the web pods.
+func (wp *Wordpress) HasMetrics() bool {
+	return wp.Spec.Metrics != nil && wp.Spec.Metrics.Enabled
+}
+
+// HasServiceMonitor returns true if a ServiceMonitor scrapes the site metrics.
+func (wp *Wordpress) HasServiceMonitor() bool {
+	return wp.HasMetrics() && wp.Spec.Metrics.ServiceMonitor != nil
+}
+
+func (wp *Wordpress) metricsExporterContainers() []corev1.Container {
+	if !wp.HasMetrics() {
+		return nil
+	}
+
+	return []corev1.Container{
+		{
+			Name:  "nginx-exporter",
+			Image: options.NginxExporterImage,
+			Args: []string{
+				fmt.Sprintf("-nginx.scrape-uri=http://127.0.0.1:%d/stub_status", nginxStatusPort),
+				fmt.Sprintf("-web.listen-address=:%d", NginxExporterPort),
+			},
+			Ports: []corev1.ContainerPort{
+				{
+					Name:          NginxExporterPortName,
+					ContainerPort: NginxExporterPort,
+				},
+			},
+		},
+		{
+			Name:  "php-fpm-exporter",
+			Image: options.PHPFPMExporterImage,
+			Args:  []string{"server"},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "PHP_FPM_SCRAPE_URI",
+					Value: options.PHPFPMStatusURI,
+				},
+				{
+					Name:  "PHP_FPM_WEB_LISTEN_ADDRESS",
+					Value: fmt.Sprintf(":%d", PHPFPMExporterPort),
+				},
+			},
+			Ports: []corev1.ContainerPort{
+				{
+					Name:          PHPFPMExporterPortName,
+					ContainerPort: PHPFPMExporterPort,
+				},
+			},
+		},
+	}
+}
+
+// HasProbe returns true if a Prometheus Operator Probe checks the site routes
+// through the blackbox exporter. Internal sites are not probed, as they have
+// no public routes.
+func (wp *Wordpress) HasProbe() bool {
+	return options.BlackboxExporterURL != "" && !wp.IsInternal()
+}
+
+// ProbeTargets returns the URLs of the site routes.
+func (wp *Wordpress) ProbeTargets() []string {
+	scheme := "http"
+	if len(wp.Spec.TLSSecretRef) > 0 {
+		scheme = "https"
+	}
+
+	seen := map[string]bool{}
+	targets := []string{}
+
+	for _, route := range wp.Spec.Routes {
+		target := fmt.Sprintf("%s://%s%s", scheme, route.Domain, path.Join("/", route.Path))
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
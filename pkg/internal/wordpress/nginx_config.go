@@ -0,0 +1,263 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// FastCGICacheConfigKey is the nginx-config ConfigMap key holding the fastcgi_cache configuration.
+	FastCGICacheConfigKey = "fastcgi-cache.conf"
+	// AccessLogConfigKey is the nginx-config ConfigMap key holding the access log configuration.
+	AccessLogConfigKey = "access-log.conf"
+
+	// JSONLogFormat writes the access logs as JSON objects.
+	JSONLogFormat = "json"
+	// CombinedLogFormat writes the access logs using the nginx combined format.
+	CombinedLogFormat = "combined"
+
+	nginxConfigVolumeName         = "nginx-config"
+	nginxConfigChecksumAnnotation = "wordpress.presslabs.org/nginxConfigChecksum"
+
+	fastCGICacheVolumeName = "fastcgi-cache"
+	fastCGICacheMountPath  = "/var/cache/nginx/fastcgi"
+)
+
+// The cache is configured in the http context, as the managed nginx config
+// can't be included in the runtime image server block. The bypass rules are
+// implemented using maps, since the set and if directives are not available
+// in the http context.
+const fastCGICacheConfigTpl = `fastcgi_cache_path {{ .path }} levels=1:2 keys_zone=wordpress:10m max_size={{ .maxSize }} inactive=60m use_temp_path=off;
+
+map $request_method $wp_cache_skip_method {
+    default 1;
+    GET     0;
+    HEAD    0;
+}
+
+map $request_uri $wp_cache_skip_uri {
+    default                                       0;
+    "~\?"                                         1;
+    "~*^/(wp-admin|wp-json|xmlrpc\.php|feed)(/|$)" 1;
+    "~*/wp-[a-z-]+\.php"                          1;
+    "~*/(sitemap(_index)?\.xml|robots\.txt)$"     1;
+}
+
+map $http_cookie $wp_cache_skip_cookie {
+    default 0;
+{{- range .bypassCookies }}
+    "~*{{ . }}" 1;
+{{- end }}
+}
+
+fastcgi_cache wordpress;
+fastcgi_cache_key "$scheme$request_method$host$request_uri";
+fastcgi_cache_valid 200 301 302 {{ .ttl }};
+fastcgi_cache_use_stale error timeout updating http_500 http_503;
+fastcgi_cache_background_update on;
+fastcgi_cache_lock on;
+fastcgi_cache_bypass $wp_cache_skip_method $wp_cache_skip_uri $wp_cache_skip_cookie;
+fastcgi_no_cache $wp_cache_skip_method $wp_cache_skip_uri $wp_cache_skip_cookie;
+add_header X-FastCGI-Cache $upstream_cache_status;
+`
+
+// The request id set by the ingress controller is logged, falling back to the
+// one generated by nginx for the requests which don't go through the ingress.
+const jsonAccessLogConfig = `map $http_x_request_id $wp_request_id {
+    default $http_x_request_id;
+    ""      $request_id;
+}
+
+log_format wordpress_json escape=json '{'
+    '"time":"$time_iso8601",'
+    '"request_id":"$wp_request_id",'
+    '"remote_addr":"$remote_addr",'
+    '"x_forwarded_for":"$http_x_forwarded_for",'
+    '"host":"$host",'
+    '"method":"$request_method",'
+    '"uri":"$request_uri",'
+    '"protocol":"$server_protocol",'
+    '"status":$status,'
+    '"body_bytes_sent":$body_bytes_sent,'
+    '"request_time":$request_time,'
+    '"upstream_response_time":"$upstream_response_time",'
+    '"upstream_cache_status":"$upstream_cache_status",'
+    '"referer":"$http_referer",'
+    '"user_agent":"$http_user_agent"'
+'}';
+
+access_log /dev/stdout wordpress_json;
+`
+
+var (
+	fastCGICacheConfigTemplate = template.Must(template.New("").Parse(fastCGICacheConfigTpl))
+
+	// defaultBypassCookies are the cookies set for the logged-in users,
+	// commenters, password protected posts and carts
+	defaultBypassCookies = []string{
+		"wordpress_logged_in",
+		"wordpress_sec_",
+		"wordpress_no_cache",
+		"wp-postpass",
+		"comment_author",
+		"woocommerce_items_in_cart",
+		"woocommerce_cart_hash",
+		"edd_items_in_cart",
+	}
+
+	// cookiePatternRegex matches the characters which can't be part of a cookie name pattern
+	cookiePatternRegex = regexp.MustCompile(`["\s;{}]`)
+)
+
+// HasFastCGICache returns true if the nginx fastcgi_cache is enabled for the site.
+func (wp *Wordpress) HasFastCGICache() bool {
+	return wp.Spec.Cache != nil && wp.Spec.Cache.FastCGI != nil
+}
+
+// HasNginxConfig returns true if nginx configuration files are managed for the site.
+func (wp *Wordpress) HasNginxConfig() bool {
+	return len(wp.NginxConfig()) > 0
+}
+
+// NginxConfig returns the nginx configuration files managed for the site,
+// keyed by file name.
+func (wp *Wordpress) NginxConfig() map[string]string {
+	out := map[string]string{}
+
+	if wp.HasFastCGICache() {
+		out[FastCGICacheConfigKey] = wp.fastCGICacheConfig()
+	}
+
+	if wp.Spec.Logging != nil && wp.Spec.Logging.Format == JSONLogFormat {
+		out[AccessLogConfigKey] = jsonAccessLogConfig
+	}
+
+	if wp.HasMetrics() {
+		out[NginxStatusConfigKey] = nginxStatusConfig
+	}
+
+	return out
+}
+
+// nginxConfigKeys returns the sorted names of the managed nginx configuration files.
+func (wp *Wordpress) nginxConfigKeys() []string {
+	config := wp.NginxConfig()
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// nginxConfigChecksum returns the checksum of the managed nginx configuration files.
+func (wp *Wordpress) nginxConfigChecksum() string {
+	config := wp.NginxConfig()
+	hash := sha256.New()
+
+	for _, key := range wp.nginxConfigKeys() {
+		fmt.Fprintf(hash, "%s\n%s", key, config[key])
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func (wp *Wordpress) fastCGICacheConfig() string {
+	cache := wp.Spec.Cache.FastCGI
+
+	cookies := append([]string{}, defaultBypassCookies...)
+	for _, cookie := range cache.BypassCookies {
+		cookies = append(cookies, cookiePatternRegex.ReplaceAllString(cookie, ""))
+	}
+
+	var out bytes.Buffer
+
+	// nolint: errcheck
+	fastCGICacheConfigTemplate.Execute(&out, map[string]interface{}{
+		"path":          fastCGICacheMountPath,
+		"maxSize":       fmt.Sprintf("%dk", cache.MaxSize.Value()/1024),
+		"ttl":           fmt.Sprintf("%ds", int64(cache.TTL.Seconds())),
+		"bypassCookies": cookies,
+	})
+
+	return strings.TrimSpace(out.String()) + "\n"
+}
+
+func (wp *Wordpress) nginxConfigVolumeMounts() []corev1.VolumeMount {
+	out := []corev1.VolumeMount{}
+
+	for _, key := range wp.nginxConfigKeys() {
+		out = append(out, corev1.VolumeMount{
+			MountPath: path.Join(options.NginxConfigDir, key),
+			Name:      nginxConfigVolumeName,
+			ReadOnly:  true,
+			SubPath:   key,
+		})
+	}
+
+	if wp.HasFastCGICache() {
+		out = append(out, corev1.VolumeMount{
+			MountPath: fastCGICacheMountPath,
+			Name:      fastCGICacheVolumeName,
+		})
+	}
+
+	return out
+}
+
+func (wp *Wordpress) nginxConfigVolumes() []corev1.Volume {
+	out := []corev1.Volume{}
+
+	if wp.HasNginxConfig() {
+		out = append(out, corev1.Volume{
+			Name: nginxConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.ComponentName(WordpressNginxConfig),
+					},
+				},
+			},
+		})
+	}
+
+	if wp.HasFastCGICache() {
+		out = append(out, corev1.Volume{
+			Name: fastCGICacheVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	return out
+}
@@ -18,6 +18,7 @@ package wordpress
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"path"
 	"strings"
@@ -27,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
 	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
 )
 
@@ -37,12 +39,32 @@ const (
 	MetricsExporterPort = 9145
 	codeVolumeName      = "code"
 	mediaVolumeName     = "media"
+	phpConfigVolumeName = "php-config"
 	s3Prefix            = "s3"
 	gcsPrefix           = "gs"
 
+	appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+	phpConfigChecksumAnnotation = "wordpress.presslabs.org/phpConfigChecksum"
+
 	prepareVolumesImage = "gcr.io/google-containers/busybox@sha256:545e6a6310a27636260920bc07b994a299b6708a1b26910cfefd335fdfb60d2b"
 )
 
+// drainScript runs the pre stop scripts, waits for the pod to be removed from
+// the service endpoints and gracefully stops nginx and php-fpm, allowing the
+// in-flight requests to finish. The processes are signaled through their pid
+// files, which the runtime image must write at $NGINX_PID_FILE and
+// $PHP_FPM_PID_FILE (or at the default paths below). The hook fails, and the
+// kubelet reports a FailedPreStopHook event, when they can't be signaled.
+const drainScript = `set -e
+if test -n "$PRE_STOP_SCRIPTS" && command -v run-parts >/dev/null 2>&1 && test -d "$PRE_STOP_SCRIPTS"  ; then run-parts --exit-on-error -v "$PRE_STOP_SCRIPTS" ; fi
+sleep %d
+nginx_pid="$(cat "${NGINX_PID_FILE:-/var/run/nginx.pid}")"
+php_fpm_pid="$(cat "${PHP_FPM_PID_FILE:-/var/run/php-fpm.pid}")"
+kill -QUIT "$nginx_pid" "$php_fpm_pid"
+while kill -0 "$nginx_pid" 2>/dev/null || kill -0 "$php_fpm_pid" 2>/dev/null ; do sleep 1 ; done
+`
+
 const gitCloneScript = `#!/bin/bash
 set -e
 set -o pipefail
@@ -71,6 +93,67 @@ cd "$SRC_DIR"
 git checkout -B "$GIT_CLONE_REF" "origin/$GIT_CLONE_REF"
 `
 
+const ociPullScript = `#!/bin/sh
+set -e
+
+export HOME="$(mktemp -d)"
+ARTIFACT_DIR="$HOME/artifact"
+
+if [ ! -z "$OCI_USERNAME" ] ; then
+    echo "$OCI_PASSWORD" | oras login --username "$OCI_USERNAME" --password-stdin "${OCI_IMAGE%%/*}"
+fi
+
+find "$SRC_DIR" -maxdepth 1 -mindepth 1 -print0 | xargs -0 /bin/rm -rf
+
+set -x
+oras pull --output "$ARTIFACT_DIR" "$OCI_IMAGE"
+
+# unpack the archived layers
+for archive in "$ARTIFACT_DIR"/*.tar "$ARTIFACT_DIR"/*.tar.gz "$ARTIFACT_DIR"/*.tgz ; do
+    test -f "$archive" || continue
+    tar -xf "$archive" -C "$ARTIFACT_DIR"
+    rm -f "$archive"
+done
+
+cp -a "$ARTIFACT_DIR/$OCI_SUBPATH/." "$SRC_DIR/"
+`
+
+const objectStorageDownloadScript = `#!/bin/sh
+set -e
+
+export HOME="$(mktemp -d)"
+ARCHIVE="$HOME/code.tar"
+
+case "$STORAGE_PROVIDER" in
+    s3)
+        export RCLONE_CONFIG_CODE_TYPE="s3"
+        export RCLONE_CONFIG_CODE_PROVIDER="Other"
+        export RCLONE_CONFIG_CODE_ENV_AUTH="true"
+        export RCLONE_CONFIG_CODE_REGION="$AWS_REGION"
+        export RCLONE_CONFIG_CODE_ENDPOINT="$S3_ENDPOINT"
+        ;;
+    gcs)
+        export RCLONE_CONFIG_CODE_TYPE="google cloud storage"
+        export RCLONE_CONFIG_CODE_ENV_AUTH="true"
+        export RCLONE_CONFIG_CODE_SERVICE_ACCOUNT_CREDENTIALS="$GOOGLE_CREDENTIALS"
+        ;;
+    *)
+        echo "Unsupported object storage provider \"$STORAGE_PROVIDER\"" >&2
+        exit 1
+        ;;
+esac
+
+find "$SRC_DIR" -maxdepth 1 -mindepth 1 -print0 | xargs -0 /bin/rm -rf
+
+set -x
+rclone copyto "code:$STORAGE_BUCKET/$STORAGE_KEY" "$ARCHIVE"
+
+case "$STORAGE_KEY" in
+    *.tar) tar -xf "$ARCHIVE" -C "$SRC_DIR" ;;
+    *) tar -xzf "$ARCHIVE" -C "$SRC_DIR" ;;
+esac
+`
+
 const prepareVolumesScriptTpl = `#!/bin/sh
 test -d /mnt/code && chown {{ .wwwDataUserID }}:{{ .wwwDataUserID }} /mnt/code
 test -d /mnt/media && chown {{ .wwwDataUserID }}:{{ .wwwDataUserID }} /mnt/media
@@ -94,6 +177,14 @@ var (
 		"GOOGLE_CREDENTIALS":             "GOOGLE_CREDENTIALS",
 		"GOOGLE_APPLICATION_CREDENTIALS": "GOOGLE_APPLICATION_CREDENTIALS",
 	}
+
+	// staticCredentialsEnvVars are omitted when using workload identity
+	staticCredentialsEnvVars = map[string]bool{
+		"AWS_ACCESS_KEY_ID":              true,
+		"AWS_SECRET_ACCESS_KEY":          true,
+		"GOOGLE_CREDENTIALS":             true,
+		"GOOGLE_APPLICATION_CREDENTIALS": true,
+	}
 )
 
 func (wp *Wordpress) mediaEnv() []corev1.EnvVar {
@@ -112,6 +203,10 @@ func (wp *Wordpress) mediaEnv() []corev1.EnvVar {
 		})
 
 		for _, env := range wp.Spec.MediaVolumeSpec.S3VolumeSource.Env {
+			if wp.UsesWorkloadIdentity() && staticCredentialsEnvVars[env.Name] {
+				continue
+			}
+
 			if name, ok := s3EnvVars[env.Name]; ok {
 				_env := env.DeepCopy()
 				_env.Name = name
@@ -129,6 +224,10 @@ func (wp *Wordpress) mediaEnv() []corev1.EnvVar {
 		})
 
 		for _, env := range wp.Spec.MediaVolumeSpec.GCSVolumeSource.Env {
+			if wp.UsesWorkloadIdentity() && staticCredentialsEnvVars[env.Name] {
+				continue
+			}
+
 			if name, ok := gcsEnvVars[env.Name]; ok {
 				_env := env.DeepCopy()
 				_env.Name = name
@@ -182,11 +281,69 @@ func (wp *Wordpress) env() []corev1.EnvVar {
 		},
 	}, wp.Spec.Env...)
 
+	// wp-cron is triggered by the operator, unless explicitly set by the user
+	if wp.IsWPCronManaged() && !hasEnv("DISABLE_WP_CRON", wp.Spec.Env) {
+		out = append(out, corev1.EnvVar{
+			Name:  "DISABLE_WP_CRON",
+			Value: "true",
+		})
+	}
+
 	out = append(out, wp.mediaEnv()...)
+	out = append(out, wp.cacheDropinsEnv()...)
+
+	// once rotated, the password from the site secret takes precedence
+	if wp.HasRotatedDBCredentials() {
+		out = append(out, corev1.EnvVar{
+			Name: "DB_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.ComponentName(WordpressSecret),
+					},
+					Key: DBPasswordKey,
+				},
+			},
+		})
+	}
 
 	return out
 }
 
+// applyComponentOverrides applies the component settings, which take
+// precedence over the site wide ones. The labels used for selecting the pods
+// can't be overridden.
+func applyComponentOverrides(out *corev1.PodTemplateSpec, c *wordpressv1alpha1.ComponentSpec) {
+	if c == nil {
+		return
+	}
+
+	out.ObjectMeta.Labels = labels.Merge(c.Labels, out.ObjectMeta.Labels)
+	out.ObjectMeta.Annotations = labels.Merge(out.ObjectMeta.Annotations, c.Annotations)
+
+	if c.Resources != nil {
+		out.Spec.Containers[0].Resources = *c.Resources
+	}
+
+	if len(c.NodeSelector) > 0 {
+		out.Spec.NodeSelector = c.NodeSelector
+	}
+
+	if len(c.Tolerations) > 0 {
+		out.Spec.Tolerations = c.Tolerations
+	}
+}
+
+func hasEnv(name string, env []corev1.EnvVar) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (wp *Wordpress) envFrom() []corev1.EnvFromSource {
 	out := []corev1.EnvFromSource{
 		{
@@ -198,6 +355,16 @@ func (wp *Wordpress) envFrom() []corev1.EnvFromSource {
 		},
 	}
 
+	if wp.HasExternalDatabaseSecret() {
+		out = append(out, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: wp.ComponentName(WordpressDatabaseSecret),
+				},
+			},
+		})
+	}
+
 	out = append(out, wp.Spec.EnvFrom...)
 
 	return out
@@ -231,6 +398,52 @@ func (wp *Wordpress) gitCloneEnv() []corev1.EnvVar {
 	return out
 }
 
+func (wp *Wordpress) ociPullEnv() []corev1.EnvVar {
+	out := []corev1.EnvVar{
+		{
+			Name:  "OCI_IMAGE",
+			Value: wp.Spec.CodeVolumeSpec.OCI.Image,
+		},
+		{
+			Name:  "OCI_SUBPATH",
+			Value: wp.Spec.CodeVolumeSpec.OCI.SubPath,
+		},
+		{
+			Name:  "SRC_DIR",
+			Value: codeSrcMountPath,
+		},
+	}
+
+	out = append(out, wp.Spec.CodeVolumeSpec.OCI.Env...)
+
+	return out
+}
+
+func (wp *Wordpress) objectStorageDownloadEnv() []corev1.EnvVar {
+	out := []corev1.EnvVar{
+		{
+			Name:  "STORAGE_PROVIDER",
+			Value: string(wp.Spec.CodeVolumeSpec.ObjectStorage.Provider),
+		},
+		{
+			Name:  "STORAGE_BUCKET",
+			Value: wp.Spec.CodeVolumeSpec.ObjectStorage.Bucket,
+		},
+		{
+			Name:  "STORAGE_KEY",
+			Value: wp.Spec.CodeVolumeSpec.ObjectStorage.Key,
+		},
+		{
+			Name:  "SRC_DIR",
+			Value: codeSrcMountPath,
+		},
+	}
+
+	out = append(out, wp.Spec.CodeVolumeSpec.ObjectStorage.Env...)
+
+	return out
+}
+
 func (wp *Wordpress) volumeMounts() []corev1.VolumeMount {
 	out := []corev1.VolumeMount{
 		{
@@ -274,6 +487,21 @@ func (wp *Wordpress) volumeMounts() []corev1.VolumeMount {
 		out = append(out, v)
 	}
 
+	if wp.HasPHPConfig() {
+		out = append(out, corev1.VolumeMount{
+			MountPath: path.Join(options.PHPConfigDir, PHPConfigKey),
+			Name:      phpConfigVolumeName,
+			ReadOnly:  true,
+			SubPath:   PHPConfigKey,
+		})
+	}
+
+	if wp.HasReadReplicas() {
+		out = append(out, wp.dbConfigVolumeMount())
+	}
+
+	out = append(out, wp.cacheDropinsVolumeMounts()...)
+
 	return out
 }
 
@@ -291,12 +519,20 @@ func (wp *Wordpress) codeVolume() corev1.Volume {
 			if wp.Spec.CodeVolumeSpec.GitDir.EmptyDir != nil {
 				codeVolume.EmptyDir = wp.Spec.CodeVolumeSpec.GitDir.EmptyDir
 			}
+		case wp.Spec.CodeVolumeSpec.OCI != nil:
+			if wp.Spec.CodeVolumeSpec.OCI.EmptyDir != nil {
+				codeVolume.EmptyDir = wp.Spec.CodeVolumeSpec.OCI.EmptyDir
+			}
+		case wp.Spec.CodeVolumeSpec.ObjectStorage != nil:
+			if wp.Spec.CodeVolumeSpec.ObjectStorage.EmptyDir != nil {
+				codeVolume.EmptyDir = wp.Spec.CodeVolumeSpec.ObjectStorage.EmptyDir
+			}
 		case wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil:
 			codeVolume = corev1.Volume{
 				Name: codeVolumeName,
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: wp.ComponentName(WordpressCodePVC),
+						ClaimName: wp.CodePVCName(),
 					},
 				},
 			}
@@ -349,6 +585,21 @@ func (wp *Wordpress) mediaVolume() corev1.Volume {
 	return mediaVolume
 }
 
+// VolumeClaims returns the names of the claims backing the site volumes,
+// keyed by the volume name (code or media).
+func (wp *Wordpress) VolumeClaims() map[string]string {
+	claims := map[string]string{}
+
+	volumes := []corev1.Volume{wp.codeVolume(), wp.mediaVolume()}
+	for i := range volumes {
+		if volumes[i].PersistentVolumeClaim != nil {
+			claims[volumes[i].Name] = volumes[i].PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	return claims
+}
+
 func (wp *Wordpress) volumes() []corev1.Volume {
 	volumes := []corev1.Volume{
 		{
@@ -376,6 +627,27 @@ func (wp *Wordpress) volumes() []corev1.Volume {
 		volumes = append(volumes, wp.mediaVolume())
 	}
 
+	if wp.HasPHPConfig() {
+		volumes = append(volumes, corev1.Volume{
+			Name: phpConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.ComponentName(WordpressPHPConfig),
+					},
+				},
+			},
+		})
+	}
+
+	if wp.HasReadReplicas() {
+		volumes = append(volumes, wp.dbConfigVolume())
+	}
+
+	if wp.HasObjectCache() {
+		volumes = append(volumes, wp.cacheDropinsVolume())
+	}
+
 	return volumes
 }
 
@@ -388,6 +660,26 @@ func (wp *Wordpress) securityContext() *corev1.SecurityContext {
 	}
 }
 
+// appArmorAnnotations returns the annotations which set the AppArmor profile
+// for every container of the given pod spec.
+func (wp *Wordpress) appArmorAnnotations(spec corev1.PodSpec) map[string]string {
+	out := map[string]string{}
+
+	if len(wp.Spec.AppArmorProfile) == 0 {
+		return out
+	}
+
+	for _, c := range spec.InitContainers {
+		out[appArmorAnnotationPrefix+c.Name] = wp.Spec.AppArmorProfile
+	}
+
+	for _, c := range spec.Containers {
+		out[appArmorAnnotationPrefix+c.Name] = wp.Spec.AppArmorProfile
+	}
+
+	return out
+}
+
 func (wp *Wordpress) gitCloneContainer() corev1.Container {
 	return corev1.Container{
 		Name:    "git",
@@ -405,6 +697,40 @@ func (wp *Wordpress) gitCloneContainer() corev1.Container {
 	}
 }
 
+func (wp *Wordpress) ociPullContainer() corev1.Container {
+	return corev1.Container{
+		Name:    "oci",
+		Args:    []string{"/bin/sh", "-c", ociPullScript},
+		Image:   options.OCIPullImage,
+		Env:     wp.ociPullEnv(),
+		EnvFrom: wp.Spec.CodeVolumeSpec.OCI.EnvFrom,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      codeVolumeName,
+				MountPath: codeSrcMountPath,
+			},
+		},
+		SecurityContext: wp.securityContext(),
+	}
+}
+
+func (wp *Wordpress) objectStorageDownloadContainer() corev1.Container {
+	return corev1.Container{
+		Name:    "object-storage",
+		Args:    []string{"/bin/sh", "-c", objectStorageDownloadScript},
+		Image:   options.ObjectStorageDownloadImage,
+		Env:     wp.objectStorageDownloadEnv(),
+		EnvFrom: wp.Spec.CodeVolumeSpec.ObjectStorage.EnvFrom,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      codeVolumeName,
+				MountPath: codeSrcMountPath,
+			},
+		},
+		SecurityContext: wp.securityContext(),
+	}
+}
+
 // nolint: funlen
 func (wp *Wordpress) prepareVolumesContainer() corev1.Container {
 	var script bytes.Buffer
@@ -504,6 +830,24 @@ func (wp *Wordpress) installWPContainer() []corev1.Container {
 	}
 }
 
+// codeContainers returns the init container fetching the code, if any.
+func (wp *Wordpress) codeContainers() []corev1.Container {
+	if wp.Spec.CodeVolumeSpec == nil {
+		return []corev1.Container{}
+	}
+
+	switch {
+	case wp.Spec.CodeVolumeSpec.GitDir != nil:
+		return []corev1.Container{wp.gitCloneContainer()}
+	case wp.Spec.CodeVolumeSpec.OCI != nil:
+		return []corev1.Container{wp.ociPullContainer()}
+	case wp.Spec.CodeVolumeSpec.ObjectStorage != nil:
+		return []corev1.Container{wp.objectStorageDownloadContainer()}
+	}
+
+	return []corev1.Container{}
+}
+
 func (wp *Wordpress) initContainers() []corev1.Container {
 	containers := []corev1.Container{}
 
@@ -512,10 +856,7 @@ func (wp *Wordpress) initContainers() []corev1.Container {
 	}
 
 	containers = append(containers, wp.Spec.InitContainers...)
-
-	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil {
-		containers = append(containers, wp.gitCloneContainer())
-	}
+	containers = append(containers, wp.codeContainers()...)
 
 	// first clone data then install wp
 	containers = append(containers, wp.installWPContainer()...)
@@ -590,8 +931,8 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 	out.ObjectMeta.Labels = labels.Merge(out.ObjectMeta.Labels, wp.WebPodLabels())
 
 	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
-	if len(wp.Spec.ServiceAccountName) > 0 {
-		out.Spec.ServiceAccountName = wp.Spec.ServiceAccountName
+	if len(wp.ServiceAccountName()) > 0 {
+		out.Spec.ServiceAccountName = wp.ServiceAccountName()
 	}
 
 	out.Spec.InitContainers = wp.initContainers()
@@ -626,8 +967,7 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 			PreStop: &corev1.Handler{
 				Exec: &corev1.ExecAction{
 					Command: []string{
-						"/bin/sh", "-c",
-						"if test -n \"$PRE_STOP_SCRIPTS\" && command -v run-parts >/dev/null 2>&1 && test -d \"$PRE_STOP_SCRIPTS\"  ; then run-parts --exit-on-error -v \"$PRE_STOP_SCRIPTS\" ; fi", // nolint: lll
+						"/bin/sh", "-c", fmt.Sprintf(drainScript, options.DrainDelaySeconds),
 					},
 				},
 			},
@@ -635,9 +975,19 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 		ReadinessProbe: wp.readinessProbe(),
 		LivenessProbe:  wp.livenessProbe(),
 	}
-	out.Spec.Containers = append([]corev1.Container{wordpressContainer}, wp.Spec.Sidecars...)
-
-	out.Spec.Volumes = wp.volumes()
+	wordpressContainer.VolumeMounts = append(wordpressContainer.VolumeMounts, wp.nginxConfigVolumeMounts()...)
+	wordpressContainer.Env = append(wordpressContainer.Env, wp.dbPoolingEnv()...)
+	out.Spec.Containers = append([]corev1.Container{wordpressContainer}, wp.logShippingContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.metricsExporterContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.dbPoolingContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.sftpContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.webDAVContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.Spec.Sidecars...)
+
+	out.Spec.Volumes = append(wp.volumes(), wp.nginxConfigVolumes()...)
+	out.Spec.Volumes = append(out.Spec.Volumes, wp.logShippingVolumes()...)
+	out.Spec.Volumes = append(out.Spec.Volumes, wp.dbPoolingVolumes()...)
+	out.Spec.Volumes = append(out.Spec.Volumes, wp.sftpVolumes()...)
 
 	if len(wp.Spec.NodeSelector) > 0 {
 		out.Spec.NodeSelector = wp.Spec.NodeSelector
@@ -653,6 +1003,53 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 		out.Spec.PriorityClassName = wp.Spec.PriorityClassName
 	}
 
+	out.Spec.SecurityContext = &corev1.PodSecurityContext{
+		SeccompProfile: wp.Spec.SeccompProfile,
+	}
+
+	out.Spec.TerminationGracePeriodSeconds = wp.Spec.TerminationGracePeriodSeconds
+	out.Spec.HostAliases = wp.Spec.HostAliases
+	out.Spec.DNSPolicy = wp.Spec.DNSPolicy
+	out.Spec.DNSConfig = wp.Spec.DNSConfig
+
+	// the php.ini file is mounted using subPath, so it doesn't get updated in place
+	if wp.HasPHPConfig() {
+		out.ObjectMeta.Annotations = labels.Merge(out.ObjectMeta.Annotations, map[string]string{
+			phpConfigChecksumAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(wp.PHPConfig()))),
+		})
+	}
+
+	if wp.HasReadReplicas() {
+		out.ObjectMeta.Annotations = labels.Merge(out.ObjectMeta.Annotations, map[string]string{
+			dbConfigChecksumAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(wp.DBConfig()))),
+		})
+	}
+
+	if wp.HasObjectCache() {
+		out.ObjectMeta.Annotations = labels.Merge(out.ObjectMeta.Annotations, map[string]string{
+			cacheDropinsChecksumAnnotation: wp.cacheDropinsChecksum(),
+		})
+	}
+
+	if wp.HasNginxConfig() {
+		out.ObjectMeta.Annotations = labels.Merge(out.ObjectMeta.Annotations, map[string]string{
+			nginxConfigChecksumAnnotation: wp.nginxConfigChecksum(),
+		})
+	}
+
+	if wp.HasLogShippingSidecar() {
+		out.ObjectMeta.Annotations = labels.Merge(out.ObjectMeta.Annotations, map[string]string{
+			loggingConfigChecksumAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(wp.logShippingConfig()))),
+		})
+	}
+
+	if wp.Spec.Components != nil {
+		applyComponentOverrides(&out, wp.Spec.Components.Web)
+	}
+
+	// annotations set through podMetadata take precedence
+	out.ObjectMeta.Annotations = labels.Merge(wp.appArmorAnnotations(out.Spec), out.ObjectMeta.Annotations)
+
 	return out
 }
 
@@ -667,8 +1064,8 @@ func (wp *Wordpress) JobPodTemplateSpec(cmd ...string) (out corev1.PodTemplateSp
 	out.ObjectMeta.Labels = labels.Merge(out.ObjectMeta.Labels, wp.JobPodLabels())
 
 	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
-	if len(wp.Spec.ServiceAccountName) > 0 {
-		out.Spec.ServiceAccountName = wp.Spec.ServiceAccountName
+	if len(wp.ServiceAccountName()) > 0 {
+		out.Spec.ServiceAccountName = wp.ServiceAccountName()
 	}
 
 	out.Spec.RestartPolicy = corev1.RestartPolicyNever
@@ -703,7 +1100,36 @@ func (wp *Wordpress) JobPodTemplateSpec(cmd ...string) (out corev1.PodTemplateSp
 	}
 
 	out.Spec.SecurityContext = &corev1.PodSecurityContext{
-		FSGroup: &wwwDataUserID,
+		FSGroup:        &wwwDataUserID,
+		SeccompProfile: wp.Spec.SeccompProfile,
+	}
+
+	out.Spec.TerminationGracePeriodSeconds = wp.Spec.TerminationGracePeriodSeconds
+	out.Spec.HostAliases = wp.Spec.HostAliases
+	out.Spec.DNSPolicy = wp.Spec.DNSPolicy
+	out.Spec.DNSConfig = wp.Spec.DNSConfig
+
+	if wp.Spec.Components != nil {
+		applyComponentOverrides(&out, wp.Spec.Components.Jobs)
+	}
+
+	// annotations set through podMetadata take precedence
+	out.ObjectMeta.Annotations = labels.Merge(wp.appArmorAnnotations(out.Spec), out.ObjectMeta.Annotations)
+
+	return out
+}
+
+// CronJobPodTemplateSpec generates a pod template spec suitable for use in the
+// wp-cli jobs run on a schedule.
+func (wp *Wordpress) CronJobPodTemplateSpec(cmd ...string) (out corev1.PodTemplateSpec) {
+	out = wp.JobPodTemplateSpec(cmd...)
+
+	if wp.Spec.Components != nil && wp.Spec.Components.Cron != nil {
+		cron := wp.Spec.Components.Cron
+		applyComponentOverrides(&out, cron)
+
+		// the cron labels take precedence over the jobs ones
+		out.ObjectMeta.Labels = labels.Merge(labels.Merge(out.ObjectMeta.Labels, cron.Labels), wp.JobPodLabels())
 	}
 
 	return out
@@ -734,6 +1160,10 @@ func (wp *Wordpress) hasCodeMounts() bool {
 	switch {
 	case wp.Spec.CodeVolumeSpec.GitDir != nil:
 		return true
+	case wp.Spec.CodeVolumeSpec.OCI != nil:
+		return true
+	case wp.Spec.CodeVolumeSpec.ObjectStorage != nil:
+		return true
 	case wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil:
 		return true
 	case wp.Spec.CodeVolumeSpec.HostPath != nil:
@@ -17,14 +17,17 @@ limitations under the License.
 package wordpress
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -92,6 +95,61 @@ var _ = Describe("Web pod spec", func() {
 		}),
 	)
 
+	DescribeTable("Should generate an OCI pull container when an OCI artifact is configured",
+		func(f func() (func() corev1.PodTemplateSpec, *Wordpress)) {
+			// we need this hack to allow wp to be initialized with our custom values
+			podSpec, w := f()
+
+			w.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+				OCI: &wordpressv1alpha1.OCIVolumeSource{
+					Image:   "ghcr.io/example/site-code:v1",
+					SubPath: "build",
+				},
+			}
+			containers := podSpec().Spec.InitContainers
+
+			Expect(containers).To(HaveLen(2))
+			Expect(containers[1].Name).To(Equal("oci"))
+			Expect(containers[1].Image).To(Equal(options.OCIPullImage))
+			Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "OCI_IMAGE", Value: "ghcr.io/example/site-code:v1"}))
+			Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "OCI_SUBPATH", Value: "build"}))
+		},
+		Entry("for web pod", func() (func() corev1.PodTemplateSpec, *Wordpress) {
+			return wp.WebPodTemplateSpec, wp
+		}),
+		Entry("for job pod", func() (func() corev1.PodTemplateSpec, *Wordpress) {
+			return func() corev1.PodTemplateSpec { return wp.JobPodTemplateSpec("test") }, wp
+		}),
+	)
+
+	DescribeTable("Should generate an object storage download container when an archive is configured",
+		func(f func() (func() corev1.PodTemplateSpec, *Wordpress)) {
+			// we need this hack to allow wp to be initialized with our custom values
+			podSpec, w := f()
+
+			w.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+				ObjectStorage: &wordpressv1alpha1.ObjectStorageVolumeSource{
+					Provider: wordpressv1alpha1.S3ObjectStorageProvider,
+					Bucket:   "builds",
+					Key:      "site/v1.tar.gz",
+				},
+			}
+			containers := podSpec().Spec.InitContainers
+
+			Expect(containers).To(HaveLen(2))
+			Expect(containers[1].Name).To(Equal("object-storage"))
+			Expect(containers[1].Image).To(Equal(options.ObjectStorageDownloadImage))
+			Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "STORAGE_PROVIDER", Value: "s3"}))
+			Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "STORAGE_KEY", Value: "site/v1.tar.gz"}))
+		},
+		Entry("for web pod", func() (func() corev1.PodTemplateSpec, *Wordpress) {
+			return wp.WebPodTemplateSpec, wp
+		}),
+		Entry("for job pod", func() (func() corev1.PodTemplateSpec, *Wordpress) {
+			return func() corev1.PodTemplateSpec { return wp.JobPodTemplateSpec("test") }, wp
+		}),
+	)
+
 	DescribeTable("Should generate an init contaniner, used to install Wordpress",
 		func(f func() (func() corev1.PodTemplateSpec, *Wordpress)) {
 			// we need this hack to allow wp to be initialized with our custom values
@@ -112,6 +170,210 @@ var _ = Describe("Web pod spec", func() {
 		}),
 	)
 
+	DescribeTable("Should set the RuntimeDefault seccomp and AppArmor profiles",
+		func(f func() func() corev1.PodTemplateSpec) {
+			podSpec := f()()
+
+			Expect(podSpec.Spec.SecurityContext).ToNot(BeNil())
+			Expect(podSpec.Spec.SecurityContext.SeccompProfile).To(Equal(&corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeRuntimeDefault,
+			}))
+
+			for _, c := range podSpec.Spec.Containers {
+				Expect(podSpec.Annotations).To(HaveKeyWithValue(
+					"container.apparmor.security.beta.kubernetes.io/"+c.Name, "runtime/default"))
+			}
+		},
+		Entry("for web pod", func() func() corev1.PodTemplateSpec { return wp.WebPodTemplateSpec }),
+		Entry("for job pod", func() func() corev1.PodTemplateSpec {
+			return func() corev1.PodTemplateSpec { return wp.JobPodTemplateSpec("test") }
+		}),
+	)
+
+	It("should allow overriding the AppArmor profile through pod annotations", func() {
+		wp.Spec.PodMetadata = &metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"container.apparmor.security.beta.kubernetes.io/wordpress": "unconfined",
+			},
+		}
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Annotations).To(HaveKeyWithValue(
+			"container.apparmor.security.beta.kubernetes.io/wordpress", "unconfined"))
+	})
+
+	It("should use the site ServiceAccount and omit static keys when using workload identity", func() {
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			S3VolumeSource: &wordpressv1alpha1.S3VolumeSource{
+				Bucket:  "test",
+				IAMRole: "arn:aws:iam::111122223333:role/test",
+				Env: []corev1.EnvVar{
+					{Name: "AWS_ACCESS_KEY_ID", Value: "key"},
+					{Name: "AWS_SECRET_ACCESS_KEY", Value: "secret"},
+					{Name: "ENDPOINT", Value: "https://s3.example.com"},
+				},
+			},
+		}
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.ServiceAccountName).To(Equal(wp.Name))
+
+		_, found := lookupEnvVar("AWS_ACCESS_KEY_ID", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+		_, found = lookupEnvVar("AWS_SECRET_ACCESS_KEY", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+		_, found = lookupEnvVar("S3_ENDPOINT", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+
+		wp.Spec.ServiceAccountName = "custom"
+		spec = wp.WebPodTemplateSpec()
+		Expect(spec.Spec.ServiceAccountName).To(Equal("custom"))
+		_, found = lookupEnvVar("AWS_ACCESS_KEY_ID", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+	})
+
+	It("should disable wp-cron on page loads when it's managed by the operator", func() {
+		spec := wp.WebPodTemplateSpec()
+		e, found := lookupEnvVar("DISABLE_WP_CRON", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("true"))
+
+		managed := false
+		wp.Spec.ManagedWPCron = &managed
+		spec = wp.WebPodTemplateSpec()
+		_, found = lookupEnvVar("DISABLE_WP_CRON", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+	})
+
+	DescribeTable("Should enforce a minimum wp-cron interval",
+		func(interval, expected time.Duration) {
+			wp.Spec.WPCronInterval = &metav1.Duration{Duration: interval}
+			wp.SetDefaults()
+			Expect(wp.Spec.WPCronInterval.Duration).To(Equal(expected))
+		},
+		Entry("zero interval", time.Duration(0), minWPCronInterval),
+		Entry("negative interval", -time.Minute, minWPCronInterval),
+		Entry("too short interval", time.Second, minWPCronInterval),
+		Entry("long enough interval", time.Minute, time.Minute),
+	)
+
+	It("should gracefully drain the web pods", func() {
+		spec := wp.WebPodTemplateSpec()
+
+		Expect(*spec.Spec.TerminationGracePeriodSeconds).To(Equal(options.TerminationGracePeriodSeconds))
+		Expect(spec.Spec.Containers[0].Lifecycle.PreStop.Exec.Command[2]).To(
+			ContainSubstring(fmt.Sprintf("sleep %d\n", options.DrainDelaySeconds)))
+	})
+
+	It("should stop the web servers through their pid files and fail otherwise", func() {
+		script := wp.WebPodTemplateSpec().Spec.Containers[0].Lifecycle.PreStop.Exec.Command[2]
+
+		Expect(script).To(HavePrefix("set -e\n"))
+		Expect(script).To(ContainSubstring(`kill -QUIT "$nginx_pid" "$php_fpm_pid"`))
+		Expect(script).NotTo(ContainSubstring("pkill"))
+		Expect(script).NotTo(ContainSubstring("pgrep"))
+		Expect(script).NotTo(ContainSubstring(">/dev/null 2>&1\n"))
+	})
+
+	DescribeTable("Should allow setting the termination grace period",
+		func(f func() func() corev1.PodTemplateSpec) {
+			gracePeriod := int64(300)
+			wp.Spec.TerminationGracePeriodSeconds = &gracePeriod
+
+			Expect(*f()().Spec.TerminationGracePeriodSeconds).To(Equal(gracePeriod))
+		},
+		Entry("for web pod", func() func() corev1.PodTemplateSpec { return wp.WebPodTemplateSpec }),
+		Entry("for job pod", func() func() corev1.PodTemplateSpec {
+			return func() corev1.PodTemplateSpec { return wp.JobPodTemplateSpec("test") }
+		}),
+	)
+
+	DescribeTable("Should set the host aliases",
+		func(f func() func() corev1.PodTemplateSpec) {
+			hostAliases := []corev1.HostAlias{
+				{
+					IP:        "10.0.0.10",
+					Hostnames: []string{"test.com", "www.test.com"},
+				},
+			}
+			wp.Spec.HostAliases = hostAliases
+
+			Expect(f()().Spec.HostAliases).To(Equal(hostAliases))
+		},
+		Entry("for web pod", func() func() corev1.PodTemplateSpec { return wp.WebPodTemplateSpec }),
+		Entry("for job pod", func() func() corev1.PodTemplateSpec {
+			return func() corev1.PodTemplateSpec { return wp.JobPodTemplateSpec("test") }
+		}),
+	)
+
+	DescribeTable("Should set the DNS policy and config",
+		func(f func() func() corev1.PodTemplateSpec) {
+			Expect(f()().Spec.DNSPolicy).To(Equal(corev1.DNSClusterFirst))
+
+			ndots := "2"
+			dnsConfig := &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.53"},
+				Searches:    []string{"db.internal"},
+				Options:     []corev1.PodDNSConfigOption{{Name: "ndots", Value: &ndots}},
+			}
+			wp.Spec.DNSPolicy = corev1.DNSNone
+			wp.Spec.DNSConfig = dnsConfig
+
+			podSpec := f()().Spec
+			Expect(podSpec.DNSPolicy).To(Equal(corev1.DNSNone))
+			Expect(podSpec.DNSConfig).To(Equal(dnsConfig))
+		},
+		Entry("for web pod", func() func() corev1.PodTemplateSpec { return wp.WebPodTemplateSpec }),
+		Entry("for job pod", func() func() corev1.PodTemplateSpec {
+			return func() corev1.PodTemplateSpec { return wp.JobPodTemplateSpec("test") }
+		}),
+	)
+
+	It("should apply the component overrides", func() {
+		wp.Spec.NodeSelector = map[string]string{"node-lifecycle": "on-demand"}
+		wp.Spec.Components = &wordpressv1alpha1.ComponentsSpec{
+			Jobs: &wordpressv1alpha1.ComponentSpec{
+				NodeSelector: map[string]string{"node-lifecycle": "spot"},
+				Labels:       map[string]string{"app.kubernetes.io/component": "overridden", "tier": "batch"},
+				Annotations:  map[string]string{"example.com/annotation": "jobs"},
+			},
+		}
+
+		web := wp.WebPodTemplateSpec()
+		Expect(web.Spec.NodeSelector).To(HaveKeyWithValue("node-lifecycle", "on-demand"))
+
+		job := wp.JobPodTemplateSpec("test")
+		Expect(job.Spec.NodeSelector).To(HaveKeyWithValue("node-lifecycle", "spot"))
+		Expect(job.Labels).To(HaveKeyWithValue("tier", "batch"))
+		Expect(job.Labels).To(HaveKeyWithValue("app.kubernetes.io/component", "wp-cli"))
+		Expect(job.Annotations).To(HaveKeyWithValue("example.com/annotation", "jobs"))
+	})
+
+	It("should apply the cron overrides on top of the jobs ones", func() {
+		wp.Spec.Components = &wordpressv1alpha1.ComponentsSpec{
+			Jobs: &wordpressv1alpha1.ComponentSpec{
+				NodeSelector: map[string]string{"node-lifecycle": "spot"},
+				Labels:       map[string]string{"tier": "batch"},
+			},
+			Cron: &wordpressv1alpha1.ComponentSpec{
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				Labels: map[string]string{"tier": "cron"},
+			},
+		}
+
+		job := wp.JobPodTemplateSpec("test")
+		Expect(job.Labels).To(HaveKeyWithValue("tier", "batch"))
+		Expect(job.Spec.Containers[0].Resources.Limits).To(BeEmpty())
+
+		cron := wp.CronJobPodTemplateSpec("test")
+		Expect(cron.Spec.NodeSelector).To(HaveKeyWithValue("node-lifecycle", "spot"))
+		Expect(cron.Labels).To(HaveKeyWithValue("tier", "cron"))
+		Expect(cron.Labels).To(HaveKeyWithValue("app.kubernetes.io/component", "wp-cli"))
+		Expect(cron.Spec.Containers[0].Resources.Limits).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("1Gi")))
+	})
+
 	It("should generate a valid STACK_ROUTES", func() {
 		spec := wp.WebPodTemplateSpec()
 		e, found := lookupEnvVar("STACK_ROUTES", spec.Spec.Containers[0].Env)
@@ -163,6 +425,18 @@ var _ = Describe("Web pod spec", func() {
 		Expect(e.Value).To(Equal("test.com,test.org/abc,test.net/xyz"))
 	})
 
+	It("should apply the common labels to the components", func() {
+		wp.Spec.CommonLabels = map[string]string{
+			"cost-center":                 "marketing",
+			"app.kubernetes.io/component": "overridden",
+		}
+
+		l := wp.ComponentLabels(WordpressDeployment)
+		Expect(l).To(HaveKeyWithValue("cost-center", "marketing"))
+		Expect(l).To(HaveKeyWithValue("app.kubernetes.io/component", "web"))
+		Expect(wp.WebPodLabels()).ToNot(HaveKey("cost-center"))
+	})
+
 	It("should give me the default domain", func() {
 		Expect(wp.MainDomain()).To(Equal("test.com"))
 
@@ -260,6 +534,430 @@ var _ = Describe("Web pod spec", func() {
 		Expect(*spec.Spec.Containers[0].LivenessProbe).To(Equal(probe))
 	})
 
+	It("mounts the php.ini directives and rolls the pods when they change", func() {
+		wp.Spec.PHPConfig = map[string]string{
+			"upload_max_filesize": "64M",
+			"memory_limit":        "256M",
+		}
+		Expect(wp.PHPConfig()).To(Equal("memory_limit = 256M\nupload_max_filesize = 64M\n"))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "php-config",
+			MountPath: "/usr/local/etc/php/conf.d/zz-wordpress-operator.ini",
+			SubPath:   "zz-wordpress-operator.ini",
+			ReadOnly:  true,
+		}))
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "php-config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: wp.Name + "-php-config"},
+				},
+			},
+		}))
+
+		checksum := spec.ObjectMeta.Annotations["wordpress.presslabs.org/phpConfigChecksum"]
+		Expect(checksum).ToNot(BeEmpty())
+
+		wp.Spec.PHPConfig["memory_limit"] = "512M"
+		Expect(wp.WebPodTemplateSpec().ObjectMeta.Annotations).To(
+			HaveKeyWithValue("wordpress.presslabs.org/phpConfigChecksum", Not(Equal(checksum))))
+	})
+
+	It("renders the OPcache settings into the php config", func() {
+		memory := int32(256)
+		revalidateFreq := int32(60)
+
+		wp.Spec.OPcache = &wordpressv1alpha1.OPcacheSpec{
+			Profile:           "prod",
+			MemoryConsumption: &memory,
+		}
+		Expect(wp.HasPHPConfig()).To(BeTrue())
+		Expect(wp.PHPConfig()).To(Equal("opcache.memory_consumption = 256\nopcache.validate_timestamps = 0\n"))
+
+		wp.Spec.OPcache.RevalidateFreq = &revalidateFreq
+		Expect(wp.PHPConfig()).To(Equal("opcache.memory_consumption = 256\nopcache.revalidate_freq = 60\nopcache.validate_timestamps = 1\n"))
+
+		wp.Spec.PHPConfig = map[string]string{"opcache.memory_consumption": "512"}
+		Expect(wp.PHPConfig()).To(ContainSubstring("opcache.memory_consumption = 512\n"))
+	})
+
+	It("configures the nginx fastcgi cache", func() {
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{
+			FastCGI: &wordpressv1alpha1.FastCGICacheSpec{
+				BypassCookies: []string{"my_session"},
+			},
+		}
+		wp.SetDefaults()
+
+		config := wp.NginxConfig()[FastCGICacheConfigKey]
+		Expect(config).To(ContainSubstring("max_size=262144k"))
+		Expect(config).To(ContainSubstring("fastcgi_cache_valid 200 301 302 60s;"))
+		Expect(config).To(ContainSubstring(`"~*wordpress_logged_in" 1;`))
+		Expect(config).To(ContainSubstring(`"~*my_session" 1;`))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "nginx-config",
+			MountPath: "/etc/nginx/conf.d/fastcgi-cache.conf",
+			SubPath:   "fastcgi-cache.conf",
+			ReadOnly:  true,
+		}))
+		Expect(spec.ObjectMeta.Annotations).To(HaveKey("wordpress.presslabs.org/nginxConfigChecksum"))
+
+		for _, m := range wp.JobPodTemplateSpec().Spec.Containers[0].VolumeMounts {
+			Expect(m.Name).ToNot(Equal("nginx-config"))
+		}
+	})
+
+	It("injects the log shipping sidecar into the web pods", func() {
+		wp.Spec.Logging = &wordpressv1alpha1.LoggingSpec{
+			Sidecar: &wordpressv1alpha1.LogShippingSidecarSpec{
+				Endpoint: "https://logs.example.com:8443/ingest",
+				Headers:  map[string]string{"Authorization": "Bearer ${LOGS_TOKEN}"},
+			},
+		}
+		wp.SetDefaults()
+
+		config := wp.LoggingConfig()["fluent-bit.conf"]
+		Expect(config).To(ContainSubstring("Host   logs.example.com\n"))
+		Expect(config).To(ContainSubstring("Port   8443\n"))
+		Expect(config).To(ContainSubstring("URI    /ingest\n"))
+		Expect(config).To(ContainSubstring("tls    On\n"))
+		Expect(config).To(ContainSubstring("Header Authorization Bearer ${LOGS_TOKEN}"))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("log-shipper"))
+		Expect(spec.Spec.Containers[1].Image).To(Equal(options.FluentBitImage))
+		Expect(spec.Spec.Containers[1].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "knative-var-log",
+			MountPath: "/var/log",
+			ReadOnly:  true,
+		}))
+		Expect(spec.ObjectMeta.Annotations).To(HaveKey("wordpress.presslabs.org/loggingConfigChecksum"))
+
+		Expect(wp.JobPodTemplateSpec().Spec.Containers).To(HaveLen(1))
+	})
+
+	It("configures vector as log shipper", func() {
+		wp.Spec.Logging = &wordpressv1alpha1.LoggingSpec{
+			Sidecar: &wordpressv1alpha1.LogShippingSidecarSpec{
+				Type:     "vector",
+				Endpoint: "http://vector-aggregator:8080",
+			},
+		}
+		wp.SetDefaults()
+
+		Expect(wp.LoggingConfig()).To(HaveKeyWithValue("vector.toml", ContainSubstring(`uri = "http://vector-aggregator:8080"`)))
+		Expect(wp.WebPodTemplateSpec().Spec.Containers[1].Image).To(Equal(options.VectorImage))
+	})
+
+	It("configures the nginx access logs format", func() {
+		wp.Spec.Logging = &wordpressv1alpha1.LoggingSpec{}
+		wp.SetDefaults()
+		Expect(wp.Spec.Logging.Format).To(Equal("combined"))
+		Expect(wp.HasNginxConfig()).To(BeFalse())
+
+		wp.Spec.Logging.Format = "json"
+		Expect(wp.NginxConfig()).To(HaveKeyWithValue("access-log.conf", ContainSubstring(`"request_id":"$wp_request_id"`)))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "nginx-config",
+			MountPath: "/etc/nginx/conf.d/access-log.conf",
+			SubPath:   "access-log.conf",
+			ReadOnly:  true,
+		}))
+		for _, v := range spec.Spec.Volumes {
+			Expect(v.Name).ToNot(Equal("fastcgi-cache"))
+		}
+	})
+
+	It("injects the metrics exporter sidecars", func() {
+		wp.Spec.Metrics = &wordpressv1alpha1.MetricsSpec{Enabled: true}
+		wp.SetDefaults()
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(3))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("nginx-exporter"))
+		Expect(spec.Spec.Containers[1].Ports[0].ContainerPort).To(Equal(int32(NginxExporterPort)))
+		Expect(spec.Spec.Containers[2].Name).To(Equal("php-fpm-exporter"))
+		Expect(spec.Spec.Containers[2].Ports[0].ContainerPort).To(Equal(int32(PHPFPMExporterPort)))
+		Expect(wp.NginxConfig()).To(HaveKeyWithValue("status.conf", ContainSubstring("stub_status;")))
+		Expect(wp.HasServiceMonitor()).To(BeFalse())
+
+		wp.Spec.Metrics.ServiceMonitor = &wordpressv1alpha1.ServiceMonitorSpec{}
+		wp.SetDefaults()
+		Expect(wp.Spec.Metrics.ServiceMonitor.Interval.Duration).To(Equal(30 * time.Second))
+	})
+
+	It("renders the Grafana dashboard", func() {
+		wp.Spec.Metrics = &wordpressv1alpha1.MetricsSpec{
+			Dashboard: &wordpressv1alpha1.GrafanaDashboardSpec{},
+		}
+		Expect(wp.HasGrafanaDashboard()).To(BeFalse())
+
+		wp.Spec.Metrics.Enabled = true
+		wp.SetDefaults()
+		Expect(wp.HasGrafanaDashboard()).To(BeTrue())
+		Expect(wp.Spec.Metrics.Dashboard.Labels).To(HaveKeyWithValue("grafana_dashboard", "1"))
+
+		dashboard := wp.GrafanaDashboard()
+		Expect(dashboard).To(Equal(wp.GrafanaDashboard()))
+		Expect(dashboard).To(ContainSubstring(fmt.Sprintf(`namespace=\"%s\", service=\"%s\"`, wp.Namespace, wp.Name)))
+		Expect(dashboard).To(ContainSubstring("phpfpm_listen_queue"))
+		Expect(dashboard).To(ContainSubstring("kube_deployment_status_replicas_available"))
+	})
+
+	It("injects the database connection pooling sidecar", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			Pooling: &wordpressv1alpha1.DatabasePoolingSpec{Enabled: true},
+		}
+		wp.SetDefaults()
+		Expect(*wp.Spec.Database.Pooling.MaxConnections).To(Equal(int32(10)))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("proxysql"))
+		Expect(spec.Spec.Containers[1].Image).To(Equal(options.ProxySQLImage))
+		Expect(spec.Spec.Containers[1].Command[2]).To(ContainSubstring("max_connections=10 }"))
+		Expect(spec.Spec.Containers[1].Env).ToNot(ContainElement(corev1.EnvVar{Name: "DB_HOST", Value: "127.0.0.1:6033"}))
+
+		env := spec.Spec.Containers[0].Env
+		Expect(env[len(env)-1]).To(Equal(corev1.EnvVar{Name: "DB_HOST", Value: "127.0.0.1:6033"}))
+
+		Expect(wp.JobPodTemplateSpec().Spec.Containers).To(HaveLen(1))
+	})
+
+	It("mounts the existing code volume claim", func() {
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			PersistentVolumeClaim: &wordpressv1alpha1.CodePersistentVolumeClaimSpec{},
+		}
+		Expect(wp.HasManagedCodePVC()).To(BeTrue())
+		Expect(wp.CodePVCName()).To(Equal(wp.Name + "-code"))
+
+		wp.Spec.CodeVolumeSpec.PersistentVolumeClaim.ClaimName = "shared-code"
+		Expect(wp.HasManagedCodePVC()).To(BeFalse())
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "code",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-code"},
+			},
+		}))
+	})
+
+	It("injects the SFTP sidecar sharing the code volume", func() {
+		wp.Spec.DeveloperAccess = &wordpressv1alpha1.DeveloperAccessSpec{
+			SFTP: &wordpressv1alpha1.SFTPSpec{
+				AuthorizedKeysSecretRef: corev1.LocalObjectReference{Name: "sftp-keys"},
+			},
+		}
+		Expect(wp.HasSFTP()).To(BeFalse())
+
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		}
+		wp.SetDefaults()
+		Expect(wp.HasSFTP()).To(BeTrue())
+		Expect(wp.Spec.DeveloperAccess.SFTP.ServiceType).To(Equal(corev1.ServiceTypeClusterIP))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("sftp"))
+		Expect(spec.Spec.Containers[1].Image).To(Equal(options.SFTPImage))
+		Expect(spec.Spec.Containers[1].Args).To(Equal([]string{"wordpress::33:33"}))
+		Expect(spec.Spec.Containers[1].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "code",
+			MountPath: "/home/wordpress/code",
+		}))
+		Expect(spec.Spec.Volumes[len(spec.Spec.Volumes)-1].Name).To(Equal("sftp-authorized-keys"))
+
+		Expect(wp.JobPodTemplateSpec().Spec.Containers).To(HaveLen(1))
+	})
+
+	It("injects the WebDAV sidecar exposing the media volume", func() {
+		wp.Spec.DeveloperAccess = &wordpressv1alpha1.DeveloperAccessSpec{
+			WebDAV: &wordpressv1alpha1.WebDAVSpec{
+				CredentialsSecretRef: corev1.LocalObjectReference{Name: "webdav-credentials"},
+				Domain:               "webdav.example.com",
+			},
+		}
+		Expect(wp.HasWebDAV()).To(BeFalse())
+
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		}
+		wp.SetDefaults()
+		Expect(wp.HasWebDAV()).To(BeTrue())
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("webdav"))
+		Expect(spec.Spec.Containers[1].Image).To(Equal(options.WebDAVImage))
+		Expect(spec.Spec.Containers[1].Args).To(Equal([]string{"serve", "webdav", "/media", "--addr", ":8081"}))
+		Expect(spec.Spec.Containers[1].Env[0].ValueFrom.SecretKeyRef.Name).To(Equal("webdav-credentials"))
+		Expect(spec.Spec.Containers[1].VolumeMounts).To(Equal([]corev1.VolumeMount{
+			{Name: "media", MountPath: "/media"},
+		}))
+
+		Expect(wp.JobPodTemplateSpec().Spec.Containers).To(HaveLen(1))
+	})
+
+	It("renders the read replicas database configuration", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			ReadReplicas: &wordpressv1alpha1.DatabaseReadReplicasSpec{},
+		}
+		Expect(wp.HasReadReplicas()).To(BeFalse())
+
+		wp.Spec.Database.ReadReplicas.Hosts = []string{"replica-0:3306"}
+		wp.Spec.Database.ReadReplicas.ServiceRef = &wordpressv1alpha1.DatabaseServiceRef{Name: "mysql-replicas"}
+		wp.SetDefaults()
+		Expect(wp.HasReadReplicas()).To(BeTrue())
+
+		config := wp.DBConfig()
+		Expect(config).To(ContainSubstring("'host'     => 'replica-0:3306',"))
+		Expect(config).To(ContainSubstring(fmt.Sprintf("'host'     => 'mysql-replicas.%s.svc:3306',", wp.Namespace)))
+
+		for _, spec := range []corev1.PodTemplateSpec{wp.WebPodTemplateSpec(), wp.JobPodTemplateSpec()} {
+			Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "db-config",
+				MountPath: "/app/web/wp-content/db-config.php",
+				SubPath:   "db-config.php",
+				ReadOnly:  true,
+			}))
+		}
+		Expect(wp.WebPodTemplateSpec().Annotations).To(HaveKey("wordpress.presslabs.org/dbConfigChecksum"))
+	})
+
+	It("mounts the cache drop-ins", func() {
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{
+			ObjectCache: &wordpressv1alpha1.ObjectCacheSpec{Backend: "redis", Host: "redis"},
+		}
+
+		dropins := wp.CacheDropins()
+		Expect(dropins).To(HaveLen(1))
+		Expect(dropins).To(HaveKeyWithValue("object-cache.php", ContainSubstring("define('WP_REDIS_PORT', 6379);")))
+
+		wp.Spec.Cache.ObjectCache = &wordpressv1alpha1.ObjectCacheSpec{Backend: "memcached", Host: "memcached:11212", PageCache: true}
+
+		dropins = wp.CacheDropins()
+		Expect(dropins).To(HaveLen(2))
+		Expect(dropins).To(HaveKeyWithValue("object-cache.php", ContainSubstring("array('memcached:11212')")))
+		Expect(dropins).To(HaveKey("advanced-cache.php"))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "WP_CACHE", Value: "true"}))
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "cache-dropins",
+			MountPath: "/app/web/wp-content/advanced-cache.php",
+			SubPath:   "advanced-cache.php",
+			ReadOnly:  true,
+		}))
+		Expect(spec.Annotations).To(HaveKey("wordpress.presslabs.org/cacheDropinsChecksum"))
+	})
+
+	It("renders the alerting rules", func() {
+		wp.Spec.Metrics = &wordpressv1alpha1.MetricsSpec{
+			Enabled: true,
+			Alerts:  &wordpressv1alpha1.AlertsSpec{},
+		}
+		wp.SetDefaults()
+		Expect(wp.Spec.Metrics.Alerts.For.Duration).To(Equal(5 * time.Minute))
+
+		rules := wp.AlertRules()
+		Expect(rules).To(HaveLen(3))
+		Expect(rules[1].Expr).To(HaveSuffix("> 5"))
+		Expect(rules[2].Expr).To(HaveSuffix("> 10"))
+
+		listenQueue := int32(50)
+		wp.Spec.Metrics.Alerts.ListenQueueLength = &listenQueue
+		wp.Spec.TLSSecretRef = "tls"
+		wp.Spec.Routes = []wordpressv1alpha1.RouteSpec{{Domain: "example.com"}, {Domain: "example.com", Path: "/blog"}}
+
+		rules = wp.AlertRules()
+		Expect(rules).To(HaveLen(4))
+		Expect(rules[2].Expr).To(HaveSuffix("> 50"))
+		Expect(rules[3].Alert).To(Equal("WordpressCertificateExpiring"))
+		Expect(rules[3].Expr).To(Equal(`min(nginx_ingress_controller_ssl_expire_time_seconds{host=~"example\\.com"}) - time() < 1209600`))
+	})
+
+	It("probes the public routes through the blackbox exporter", func() {
+		Expect(wp.HasProbe()).To(BeFalse())
+
+		options.BlackboxExporterURL = "blackbox-exporter.monitoring:9115"
+		defer func() { options.BlackboxExporterURL = "" }()
+		Expect(wp.HasProbe()).To(BeTrue())
+
+		wp.Spec.TLSSecretRef = "test-tls"
+		wp.Spec.Routes = append(wp.Spec.Routes,
+			wordpressv1alpha1.RouteSpec{Domain: "test.com", Path: "/blog"},
+			wordpressv1alpha1.RouteSpec{Domain: "test.com"},
+		)
+		Expect(wp.ProbeTargets()).To(Equal([]string{"https://test.com/", "https://test.com/blog"}))
+
+		wp.Spec.Routing = &wordpressv1alpha1.RoutingSpec{Internal: true}
+		Expect(wp.HasProbe()).To(BeFalse())
+	})
+
+	It("partitions the sites between the shards", func() {
+		Expect(wp.InShard()).To(BeTrue())
+
+		options.Shards = 3
+		defer func() { options.Shards, options.Shard = 1, 0 }()
+
+		owners := 0
+		for shard := int32(0); shard < options.Shards; shard++ {
+			options.Shard = shard
+			if wp.InShard() {
+				owners++
+			}
+		}
+		Expect(owners).To(Equal(1))
+
+		wp.ObjectMeta.Labels[ShardLabel] = "5"
+		Expect(wp.Shard()).To(Equal(int32(2)))
+	})
+
+	It("renders the extra resources", func() {
+		options.ExtraResourceKinds = []string{"ConfigMap"}
+		defer func() { options.ExtraResourceKinds = nil }()
+
+		wp.Spec.ExtraResources = []string{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Name }}-cdn\ndata:\n  origin: {{ .MainDomain }}\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Missing }}\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foreign\n  namespace: other\n",
+			"apiVersion: v1\nkind: Secret\nmetadata:\n  name: {{ .Name }}-secret\n",
+		}
+
+		objs, err := wp.ExtraResources()
+		Expect(errors.Is(err, ErrInvalidExtraResource)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("extraResources[1]"))
+		Expect(err.Error()).To(ContainSubstring("extraResources[2]"))
+		Expect(err.Error()).To(ContainSubstring("extraResources[3]"))
+
+		Expect(objs).To(HaveLen(1))
+		Expect(objs[0].GetName()).To(Equal(wp.Name + "-cdn"))
+		Expect(objs[0].GetNamespace()).To(Equal(wp.Namespace))
+		Expect(objs[0].Object["data"]).To(HaveKeyWithValue("origin", "test.com"))
+	})
+
+	DescribeTable("selecting the PHP version",
+		func(image, phpVersion, expected string) {
+			wp.Spec.Image = image
+			wp.Spec.PHPVersion = phpVersion
+			wp.SetDefaults()
+
+			Expect(wp.WebPodTemplateSpec().Spec.Containers[0].Image).To(Equal(expected))
+		},
+		Entry("appends the PHP suffix", "docker.io/bitpoke/wordpress-runtime:5.8.2", "8.0", "docker.io/bitpoke/wordpress-runtime:5.8.2-php80"),
+		Entry("replaces the PHP suffix", "docker.io/bitpoke/wordpress-runtime:5.8.2-php74", "8.0", "docker.io/bitpoke/wordpress-runtime:5.8.2-php80"),
+		Entry("leaves images pinned to a digest", "docker.io/bitpoke/wordpress-runtime@sha256:abcd", "8.0", "docker.io/bitpoke/wordpress-runtime@sha256:abcd"),
+		Entry("leaves the image when no version is set", "docker.io/bitpoke/wordpress-runtime:5.8.2-php74", "", "docker.io/bitpoke/wordpress-runtime:5.8.2-php74"),
+	)
 })
 
 // nolint: unparam
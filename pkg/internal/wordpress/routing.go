@@ -0,0 +1,73 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// IngressAffinityAnnotation selects the ingress-nginx affinity mode.
+	IngressAffinityAnnotation = "nginx.ingress.kubernetes.io/affinity"
+	// IngressSessionCookieNameAnnotation sets the ingress-nginx affinity cookie name.
+	IngressSessionCookieNameAnnotation = "nginx.ingress.kubernetes.io/session-cookie-name"
+	// IngressSessionCookieMaxAgeAnnotation sets the ingress-nginx affinity cookie max age.
+	IngressSessionCookieMaxAgeAnnotation = "nginx.ingress.kubernetes.io/session-cookie-max-age"
+)
+
+// HasStickySessions returns true if the clients are pinned to the same web pod.
+func (wp *Wordpress) HasStickySessions() bool {
+	return wp.Spec.Routing != nil && wp.Spec.Routing.StickySessions != nil
+}
+
+// IsInternal returns true if the site is reachable only through the web Service,
+// either because it's explicitly marked as internal or it has no routes.
+func (wp *Wordpress) IsInternal() bool {
+	return len(wp.Spec.Routes) == 0 || (wp.Spec.Routing != nil && wp.Spec.Routing.Internal)
+}
+
+// ServiceType returns the type of the web Service.
+func (wp *Wordpress) ServiceType() corev1.ServiceType {
+	if wp.Spec.Service == nil || wp.Spec.Service.Type == "" {
+		return corev1.ServiceTypeClusterIP
+	}
+
+	return wp.Spec.Service.Type
+}
+
+// StickySessionsIngressAnnotations returns the ingress annotations which
+// enable cookie affinity, or nil if sticky sessions are disabled.
+func (wp *Wordpress) StickySessionsIngressAnnotations() map[string]string {
+	if !wp.HasStickySessions() {
+		return nil
+	}
+
+	sticky := wp.Spec.Routing.StickySessions
+
+	annotations := map[string]string{
+		IngressAffinityAnnotation:          "cookie",
+		IngressSessionCookieNameAnnotation: sticky.CookieName,
+	}
+
+	if sticky.TimeoutSeconds != nil {
+		annotations[IngressSessionCookieMaxAgeAnnotation] = fmt.Sprintf("%d", *sticky.TimeoutSeconds)
+	}
+
+	return annotations
+}
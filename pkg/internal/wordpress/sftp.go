@@ -0,0 +1,145 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SFTPPort is the port on which the SFTP sidecar accepts connections.
+	SFTPPort = 22
+	// SFTPPortName is the name of the SFTP sidecar port.
+	SFTPPortName = "sftp"
+
+	// the files are edited as the user running the site
+	sftpUser        = "wordpress"
+	sftpHomeDir     = "/home/" + sftpUser
+	sftpHostKeysDir = "/etc/ssh"
+
+	sftpContainerName          = "sftp"
+	sftpAuthorizedKeysVolume   = "sftp-authorized-keys"
+	sftpHostKeysVolume         = "sftp-host-keys"
+	sftpAuthorizedKeysKey      = "authorized_keys"
+	sftpHostKeysMode           = int32(0o400)
+	sftpAuthorizedKeysFileMode = int32(0o444)
+)
+
+var sftpHostKeyFiles = []string{"ssh_host_ed25519_key", "ssh_host_rsa_key"}
+
+// HasSFTP returns true if the SFTP sidecar is injected into the web pods.
+// There is nothing to share without a code volume.
+func (wp *Wordpress) HasSFTP() bool {
+	return wp.Spec.DeveloperAccess != nil && wp.Spec.DeveloperAccess.SFTP != nil && wp.hasCodeMounts()
+}
+
+func (wp *Wordpress) sftpContainers() []corev1.Container {
+	if !wp.HasSFTP() {
+		return nil
+	}
+
+	sftp := wp.Spec.DeveloperAccess.SFTP
+
+	// the users are chrooted into their home, which must be owned by root,
+	// so the code gets mounted in a subdirectory
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      codeVolumeName,
+			MountPath: path.Join(sftpHomeDir, "code"),
+			ReadOnly:  wp.Spec.CodeVolumeSpec.ReadOnly,
+		},
+		{
+			Name:      sftpAuthorizedKeysVolume,
+			MountPath: path.Join(sftpHomeDir, ".ssh/keys"),
+			ReadOnly:  true,
+		},
+	}
+
+	if sftp.HostKeysSecretRef != nil {
+		for _, key := range sftpHostKeyFiles {
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      sftpHostKeysVolume,
+				MountPath: path.Join(sftpHostKeysDir, key),
+				SubPath:   key,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	return []corev1.Container{
+		{
+			Name:  sftpContainerName,
+			Image: sftp.Image,
+			Args:  []string{fmt.Sprintf("%s::%d:%d", sftpUser, wwwDataUserID, wwwDataUserID)},
+			Ports: []corev1.ContainerPort{
+				{
+					Name:          SFTPPortName,
+					ContainerPort: int32(SFTPPort),
+					Protocol:      corev1.ProtocolTCP,
+				},
+			},
+			Resources:    sftp.Resources,
+			VolumeMounts: mounts,
+		},
+	}
+}
+
+func (wp *Wordpress) sftpVolumes() []corev1.Volume {
+	if !wp.HasSFTP() {
+		return nil
+	}
+
+	sftp := wp.Spec.DeveloperAccess.SFTP
+	authorizedKeysMode := sftpAuthorizedKeysFileMode
+
+	volumes := []corev1.Volume{
+		{
+			Name: sftpAuthorizedKeysVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: sftp.AuthorizedKeysSecretRef.Name,
+					Items: []corev1.KeyToPath{
+						{
+							Key:  sftpAuthorizedKeysKey,
+							Path: sftpAuthorizedKeysKey + ".pub",
+						},
+					},
+					DefaultMode: &authorizedKeysMode,
+				},
+			},
+		},
+	}
+
+	if sftp.HostKeysSecretRef != nil {
+		hostKeysMode := sftpHostKeysMode
+
+		volumes = append(volumes, corev1.Volume{
+			Name: sftpHostKeysVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  sftp.HostKeysSecretRef.Name,
+					DefaultMode: &hostKeysMode,
+				},
+			},
+		})
+	}
+
+	return volumes
+}
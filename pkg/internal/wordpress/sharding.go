@@ -0,0 +1,49 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// ShardLabel pins a site to a shard, instead of the one picked by hashing
+// its namespace and name.
+const ShardLabel = "wordpress.presslabs.org/shard"
+
+// Shard returns the partition the site belongs to, out of --shards.
+func (wp *Wordpress) Shard() int32 {
+	if options.Shards <= 1 {
+		return 0
+	}
+
+	if shard, err := strconv.ParseUint(wp.ObjectMeta.Labels[ShardLabel], 10, 32); err == nil {
+		return int32(shard % uint64(options.Shards))
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(wp.Namespace + "/" + wp.Name))
+
+	return int32(h.Sum32() % uint32(options.Shards))
+}
+
+// InShard returns true if the site is reconciled by this operator replica.
+func (wp *Wordpress) InShard() bool {
+	return options.Shards <= 1 || wp.Shard() == options.Shard
+}
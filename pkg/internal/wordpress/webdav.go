@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// WebDAVPort is the port on which the WebDAV sidecar accepts connections.
+	WebDAVPort = 8081
+	// WebDAVPortName is the name of the WebDAV sidecar port.
+	WebDAVPortName = "webdav"
+
+	webDAVContainerName = "webdav"
+	webDAVMediaPath     = "/media"
+)
+
+// HasWebDAV returns true if the WebDAV sidecar is injected into the web pods.
+// Media stored in buckets is not mounted, so it can't be exposed.
+func (wp *Wordpress) HasWebDAV() bool {
+	return wp.Spec.DeveloperAccess != nil && wp.Spec.DeveloperAccess.WebDAV != nil && wp.hasMediaMounts()
+}
+
+func (wp *Wordpress) webDAVContainers() []corev1.Container {
+	if !wp.HasWebDAV() {
+		return nil
+	}
+
+	webdav := wp.Spec.DeveloperAccess.WebDAV
+	credential := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: webdav.CredentialsSecretRef,
+				Key:                  key,
+			},
+		}
+	}
+
+	return []corev1.Container{
+		{
+			Name:  webDAVContainerName,
+			Image: webdav.Image,
+			Args:  []string{"serve", "webdav", webDAVMediaPath, "--addr", fmt.Sprintf(":%d", WebDAVPort)},
+			// the credentials are passed through the environment, so they
+			// don't show up in the process list
+			Env: []corev1.EnvVar{
+				{Name: "RCLONE_USER", ValueFrom: credential("username")},
+				{Name: "RCLONE_PASS", ValueFrom: credential("password")},
+			},
+			Ports: []corev1.ContainerPort{
+				{
+					Name:          WebDAVPortName,
+					ContainerPort: int32(WebDAVPort),
+					Protocol:      corev1.ProtocolTCP,
+				},
+			},
+			Resources: webdav.Resources,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      mediaVolumeName,
+					MountPath: webDAVMediaPath,
+					ReadOnly:  wp.Spec.MediaVolumeSpec.ReadOnly,
+					SubPath:   wp.Spec.MediaVolumeSpec.ContentSubPath,
+				},
+			},
+			// the uploads are owned by the user running the site
+			SecurityContext: wp.securityContext(),
+		},
+	}
+}
@@ -17,13 +17,49 @@ limitations under the License.
 package wordpress
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"path"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/cooleo/slugify"
 	"k8s.io/apimachinery/pkg/labels"
 
 	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// RotateDBCredentialsAnnotation triggers a database credentials rotation every time its value changes.
+	// The rotation retains the current password until the pods roll out, so the database must support dual passwords.
+	RotateDBCredentialsAnnotation = "wordpress.presslabs.org/rotate-db-credentials"
+	// ExportContentAnnotation triggers a WXR export of the site content, which
+	// gets uploaded (HTTP PUT) to the URL set as value (eg. a pre-signed object storage URL).
+	ExportContentAnnotation = "wordpress.presslabs.org/export-content"
+	// ImportContentAnnotation triggers an import into the site of the WXR file
+	// downloaded from the URL set as value.
+	ImportContentAnnotation = "wordpress.presslabs.org/import-content"
+	// DebugShellAnnotation requests, while set to "true", a pod running the
+	// site image, env and volumes, for troubleshooting through kubectl exec.
+	DebugShellAnnotation = "wordpress.presslabs.org/debug-shell"
+
+	// BlueprintLabel is set, to the site name, on the namespace provisioned for
+	// a site created in the blueprint namespace and on the objects inside it.
+	BlueprintLabel = "wordpress.presslabs.org/blueprint"
+
+	// DBPasswordKey is the site secret key holding the database password, once rotated by the operator.
+	DBPasswordKey = "DB_PASSWORD"
+	// NextDBPasswordKey is the key holding the new database password during a rotation. It is kept
+	// into a dedicated secret, read only by the rotation job, so the web pods don't roll for it.
+	NextDBPasswordKey = "NEXT_DB_PASSWORD"
+
+	// PHPConfigKey is the php-config ConfigMap key holding the php.ini directives.
+	PHPConfigKey = "zz-wordpress-operator.ini"
+
+	opcacheDevProfile  = "dev"
+	opcacheProdProfile = "prod"
 )
 
 // Wordpress embeds wordpressv1alpha1.Wordpress and adds utility functions.
@@ -54,6 +90,60 @@ var (
 	WordpressCodePVC = component{name: "code", objNameFmt: "%s-code"}
 	// WordpressMediaPVC component.
 	WordpressMediaPVC = component{name: "media", objNameFmt: "%s-media"}
+	// WordpressDatabaseSecret component.
+	WordpressDatabaseSecret = component{name: "database", objNameFmt: "%s-db"}
+	// WordpressExternalSecret component.
+	WordpressExternalSecret = component{name: "database", objNameFmt: "%s-db"}
+	// WordpressVPA component.
+	WordpressVPA = component{name: "web", objNameFmt: "%s"}
+	// WordpressScaledObject component.
+	WordpressScaledObject = component{name: "web", objNameFmt: "%s"}
+	// WordpressMediaBucket component.
+	WordpressMediaBucket = component{name: "media-bucket", objNameFmt: "%s-media"}
+	// WordpressServiceAccount component.
+	WordpressServiceAccount = component{name: "web", objNameFmt: "%s"}
+	// WordpressPHPConfig component.
+	WordpressPHPConfig = component{name: "web", objNameFmt: "%s-php-config"}
+	// WordpressNginxConfig component.
+	WordpressNginxConfig = component{name: "web", objNameFmt: "%s-nginx-config"}
+	// WordpressLoggingConfig component.
+	WordpressLoggingConfig = component{name: "web", objNameFmt: "%s-logging"}
+	// WordpressServiceMonitor component.
+	WordpressServiceMonitor = component{name: "web", objNameFmt: "%s"}
+	// WordpressGrafanaDashboard component.
+	WordpressGrafanaDashboard = component{name: "web", objNameFmt: "%s-grafana-dashboard"}
+	// WordpressProbe component.
+	WordpressProbe = component{name: "web", objNameFmt: "%s"}
+	// WordpressPrometheusRule component.
+	WordpressPrometheusRule = component{name: "web", objNameFmt: "%s"}
+	// WordpressDBCredentialsRotation component.
+	WordpressDBCredentialsRotation = component{name: "db-credentials-rotation", objNameFmt: "%s-db-rotate"}
+	// WordpressDBCredentialsSecret component.
+	WordpressDBCredentialsSecret = component{name: "db-credentials-rotation", objNameFmt: "%s-db-credentials"}
+	// WordpressDBCredentialsDiscard component.
+	WordpressDBCredentialsDiscard = component{name: "db-credentials-rotation", objNameFmt: "%s-db-discard"}
+	// WordpressContentExport component.
+	WordpressContentExport = component{name: "content-export", objNameFmt: "%s-export"}
+	// WordpressContentImport component.
+	WordpressContentImport = component{name: "content-import", objNameFmt: "%s-import"}
+	// WordpressDBConfig component.
+	WordpressDBConfig = component{name: "web", objNameFmt: "%s-db-config"}
+	// WordpressCacheDropins component.
+	WordpressCacheDropins = component{name: "web", objNameFmt: "%s-cache-dropins"}
+	// WordpressMediaGC component.
+	WordpressMediaGC = component{name: "media-gc", objNameFmt: "%s-media-gc"}
+	// WordpressDBMaintenance component.
+	WordpressDBMaintenance = component{name: "db-maintenance", objNameFmt: "%s-db-maintenance"}
+	// WordpressSFTPService component.
+	WordpressSFTPService = component{name: "sftp", objNameFmt: "%s-sftp"}
+	// WordpressWebDAV component.
+	WordpressWebDAV = component{name: "webdav", objNameFmt: "%s-webdav"}
+	// WordpressDebugShell component.
+	WordpressDebugShell = component{name: "debug-shell", objNameFmt: "%s-debug"}
+	// WordpressAdminUser component.
+	WordpressAdminUser = component{name: "admin-user", objNameFmt: "%s-admin"}
+	// WordpressExtraResource component.
+	WordpressExtraResource = component{name: "extra"}
 )
 
 // New wraps a wordpressv1alpha1.Wordpress into a Wordpress object.
@@ -84,7 +174,7 @@ func (wp *Wordpress) Labels() labels.Set {
 
 // ComponentLabels returns labels for a label set for a wordpressv1alpha1.Wordpress component.
 func (wp *Wordpress) ComponentLabels(component component) labels.Set {
-	l := wp.Labels()
+	l := labels.Merge(wp.Spec.CommonLabels, wp.Labels())
 	l["app.kubernetes.io/component"] = component.name
 
 	if component == WordpressDBUpgrade {
@@ -105,9 +195,247 @@ func (wp *Wordpress) ComponentName(component component) string {
 		name = fmt.Sprintf("%s-for-%s", name, wp.ImageVersion())
 	}
 
+	if component == WordpressDBCredentialsRotation {
+		// the annotation value is arbitrary, so use a hash of it
+		hash := sha256.Sum256([]byte(wp.PendingDBCredentialsRotation()))
+		name = fmt.Sprintf("%s-%x", name, hash[:4])
+	}
+
+	if component == WordpressDBCredentialsDiscard && wp.Status.Database != nil {
+		hash := sha256.Sum256([]byte(wp.Status.Database.CredentialsRotationToken))
+		name = fmt.Sprintf("%s-%x", name, hash[:4])
+	}
+
+	if component == WordpressContentExport {
+		name = fmt.Sprintf("%s-%s", name, ContentTransferToken(wp.PendingContentExport())[:8])
+	}
+
+	if component == WordpressContentImport {
+		name = fmt.Sprintf("%s-%s", name, ContentTransferToken(wp.PendingContentImport())[:8])
+	}
+
 	return name
 }
 
+// HasExternalDatabaseSecret returns true if the database credentials are
+// fetched from an external secret store.
+func (wp *Wordpress) HasExternalDatabaseSecret() bool {
+	return wp.Spec.Database != nil && wp.Spec.Database.ExternalSecretRef != nil
+}
+
+// HasDebugShell returns true if a debug shell pod is requested for the site.
+func (wp *Wordpress) HasDebugShell() bool {
+	return wp.ObjectMeta.Annotations[DebugShellAnnotation] == "true"
+}
+
+// IsBlueprint returns true if the site is created in the blueprint namespace,
+// so it gets deployed in a dedicated, provisioned namespace.
+func (wp *Wordpress) IsBlueprint() bool {
+	return options.BlueprintNamespace != "" && wp.Namespace == options.BlueprintNamespace
+}
+
+// ProvisionedNamespace returns the name of the namespace provisioned for a site
+// created in the blueprint namespace.
+func (wp *Wordpress) ProvisionedNamespace() string {
+	return options.ProvisionedNamespacePrefix + wp.Name
+}
+
+// PendingDBCredentialsRotation returns the token of the requested database
+// credentials rotation or an empty string if there is no rotation pending.
+func (wp *Wordpress) PendingDBCredentialsRotation() string {
+	token := wp.ObjectMeta.Annotations[RotateDBCredentialsAnnotation]
+	if token == "" {
+		return ""
+	}
+
+	if wp.Status.Database != nil && wp.Status.Database.CredentialsRotationToken == token {
+		return ""
+	}
+
+	return token
+}
+
+// ContentTransferToken returns the token identifying a content export or
+// import. The URL may embed credentials, so a hash of it is used.
+func ContentTransferToken(url string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(url)))
+}
+
+// PendingContentExport returns the URL of the requested content export or an
+// empty string if there is no export pending.
+func (wp *Wordpress) PendingContentExport() string {
+	url := wp.ObjectMeta.Annotations[ExportContentAnnotation]
+	if url == "" {
+		return ""
+	}
+
+	if wp.Status.Content != nil && wp.Status.Content.Export != nil && wp.Status.Content.Export.Token == ContentTransferToken(url) {
+		return ""
+	}
+
+	return url
+}
+
+// PendingContentImport returns the URL of the requested content import or an
+// empty string if there is no import pending.
+func (wp *Wordpress) PendingContentImport() string {
+	url := wp.ObjectMeta.Annotations[ImportContentAnnotation]
+	if url == "" {
+		return ""
+	}
+
+	if wp.Status.Content != nil && wp.Status.Content.Import != nil && wp.Status.Content.Import.Token == ContentTransferToken(url) {
+		return ""
+	}
+
+	return url
+}
+
+// HasRotatedDBCredentials returns true if the database password was rotated
+// by the operator and thus is stored in the site secret.
+func (wp *Wordpress) HasRotatedDBCredentials() bool {
+	return !wp.HasExternalDatabaseSecret() && wp.Status.Database != nil && wp.Status.Database.CredentialsRotationTime != nil
+}
+
+// IsWPCronManaged returns true if wp-cron is triggered by the operator.
+func (wp *Wordpress) IsWPCronManaged() bool {
+	return wp.Spec.ManagedWPCron == nil || *wp.Spec.ManagedWPCron
+}
+
+// HasMediaGC returns true if the orphaned media files are collected periodically.
+func (wp *Wordpress) HasMediaGC() bool {
+	return wp.Spec.MediaGC != nil
+}
+
+// HasDBMaintenance returns true if the database is optimized periodically.
+func (wp *Wordpress) HasDBMaintenance() bool {
+	return wp.Spec.DBMaintenance != nil
+}
+
+// HasPHPConfig returns true if php.ini directives are set for the site.
+func (wp *Wordpress) HasPHPConfig() bool {
+	return len(wp.Spec.PHPConfig) > 0 || wp.Spec.OPcache != nil
+}
+
+// PHPConfig renders the php.ini directives set for the site, sorted by name.
+func (wp *Wordpress) PHPConfig() string {
+	directives := labels.Merge(wp.opcacheDirectives(), wp.Spec.PHPConfig)
+
+	names := make([]string, 0, len(directives))
+	for name := range directives {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s = %s\n", name, directives[name])
+	}
+
+	return out.String()
+}
+
+// opcacheDirectives returns the php.ini directives for the OPcache settings.
+func (wp *Wordpress) opcacheDirectives() map[string]string {
+	out := map[string]string{}
+
+	opcache := wp.Spec.OPcache
+	if opcache == nil {
+		return out
+	}
+
+	switch opcache.Profile {
+	case opcacheDevProfile:
+		out["opcache.validate_timestamps"] = "1"
+		out["opcache.revalidate_freq"] = "0"
+	case opcacheProdProfile:
+		out["opcache.validate_timestamps"] = "0"
+	}
+
+	if opcache.MemoryConsumption != nil {
+		out["opcache.memory_consumption"] = strconv.Itoa(int(*opcache.MemoryConsumption))
+	}
+
+	if opcache.MaxAcceleratedFiles != nil {
+		out["opcache.max_accelerated_files"] = strconv.Itoa(int(*opcache.MaxAcceleratedFiles))
+	}
+
+	if opcache.RevalidateFreq != nil {
+		out["opcache.validate_timestamps"] = "1"
+		out["opcache.revalidate_freq"] = strconv.Itoa(int(*opcache.RevalidateFreq))
+	}
+
+	return out
+}
+
+// PinsImageDigest returns true if the image tag is resolved to a digest, which
+// gets pinned in the web deployment and the jobs.
+func (wp *Wordpress) PinsImageDigest() bool {
+	return wp.Spec.ImagePolicy != nil && wp.Spec.ImagePolicy.PinDigest
+}
+
+// HasVerticalAutoscaling returns true if a VerticalPodAutoscaler is configured for the web deployment.
+func (wp *Wordpress) HasVerticalAutoscaling() bool {
+	return wp.Spec.Autoscaling != nil && wp.Spec.Autoscaling.Vertical != nil
+}
+
+// HasKEDAAutoscaling returns true if the web deployment is scaled by KEDA.
+func (wp *Wordpress) HasKEDAAutoscaling() bool {
+	return wp.Spec.Autoscaling != nil && wp.Spec.Autoscaling.KEDA != nil
+}
+
+// UsesWorkloadIdentity returns true if the media bucket is accessed through
+// the cloud provider workload identity, using the site ServiceAccount.
+func (wp *Wordpress) UsesWorkloadIdentity() bool {
+	if len(wp.Spec.ServiceAccountName) > 0 || wp.Spec.MediaVolumeSpec == nil {
+		return false
+	}
+
+	if wp.Spec.MediaVolumeSpec.S3VolumeSource != nil && len(wp.Spec.MediaVolumeSpec.S3VolumeSource.IAMRole) > 0 {
+		return true
+	}
+
+	if wp.Spec.MediaVolumeSpec.GCSVolumeSource != nil && len(wp.Spec.MediaVolumeSpec.GCSVolumeSource.ServiceAccount) > 0 {
+		return true
+	}
+
+	return false
+}
+
+// ShouldProvisionBucket returns true if the media bucket should be provisioned by the operator.
+func (wp *Wordpress) ShouldProvisionBucket() bool {
+	if wp.Spec.MediaVolumeSpec == nil || !wp.Spec.MediaVolumeSpec.ProvisionBucket {
+		return false
+	}
+
+	return wp.Spec.MediaVolumeSpec.S3VolumeSource != nil || wp.Spec.MediaVolumeSpec.GCSVolumeSource != nil
+}
+
+// ServiceAccountName returns the name of the ServiceAccount used to run the site pods.
+func (wp *Wordpress) ServiceAccountName() string {
+	if wp.UsesWorkloadIdentity() {
+		return wp.ComponentName(WordpressServiceAccount)
+	}
+
+	return wp.Spec.ServiceAccountName
+}
+
+// HasManagedCodePVC returns true if the code volume claim is created for the site.
+func (wp *Wordpress) HasManagedCodePVC() bool {
+	return wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil &&
+		wp.Spec.CodeVolumeSpec.PersistentVolumeClaim.ClaimName == ""
+}
+
+// CodePVCName returns the name of the claim backing the code volume.
+func (wp *Wordpress) CodePVCName() string {
+	if wp.HasManagedCodePVC() {
+		return wp.ComponentName(WordpressCodePVC)
+	}
+
+	return wp.Spec.CodeVolumeSpec.PersistentVolumeClaim.ClaimName
+}
+
 // ImageVersion returns the version from the image in a format suitable
 // for kubernetes object names and labels.
 func (wp *Wordpress) ImageVersion() string {
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// routeDomainField is the cache index of the Wordpress resources by their route domains.
+const routeDomainField = "spec.routes.domain"
+
+// routeDomains returns the normalized domains routed to a site.
+func routeDomains(obj client.Object) []string {
+	wp, ok := obj.(*wordpressv1alpha1.Wordpress)
+	if !ok || isBlueprint(wp) {
+		return nil
+	}
+
+	var domains []string
+
+	seen := map[string]bool{}
+
+	for _, route := range wp.Spec.Routes {
+		domain := normalizeDomain(route.Domain)
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
+
+// validateDomains returns the routes of the site which are already claimed by
+// other sites in the cluster. Two sites may share a domain as long as they
+// are routed on different paths.
+func (v *wordpressValidator) validateDomains(ctx context.Context, wp *wordpressv1alpha1.Wordpress) (field.ErrorList, error) {
+	var errs field.ErrorList
+
+	for _, domain := range routeDomains(wp) {
+		sites := &wordpressv1alpha1.WordpressList{}
+		if err := v.client.List(ctx, sites, client.MatchingFields{routeDomainField: domain}); err != nil {
+			return nil, err
+		}
+
+		for i := range sites.Items {
+			errs = append(errs, conflictingRoutes(wp, &sites.Items[i], domain)...)
+		}
+	}
+
+	return errs, nil
+}
+
+// conflictingRoutes returns the routes of wp on the given domain which are
+// also routed to the other site.
+func conflictingRoutes(wp, other *wordpressv1alpha1.Wordpress, domain string) field.ErrorList {
+	if other.Namespace == wp.Namespace && other.Name == wp.Name {
+		return nil
+	}
+
+	// the sites being deleted release their routes
+	if !other.DeletionTimestamp.IsZero() || isBlueprint(other) {
+		return nil
+	}
+
+	claimed := map[string]bool{}
+
+	for _, route := range other.Spec.Routes {
+		if normalizeDomain(route.Domain) == domain {
+			claimed[normalizePath(route.Path)] = true
+		}
+	}
+
+	var errs field.ErrorList
+
+	routes := field.NewPath("spec", "routes")
+
+	for i, route := range wp.Spec.Routes {
+		if normalizeDomain(route.Domain) == domain && claimed[normalizePath(route.Path)] {
+			errs = append(errs, field.Invalid(routes.Index(i), route.Domain+route.Path,
+				fmt.Sprintf("is already routed to the %s/%s site", other.Namespace, other.Name)))
+		}
+	}
+
+	return errs
+}
+
+// isBlueprint returns true for the sites which are only copied into the
+// provisioned namespaces, so their routes are not served.
+func isBlueprint(wp *wordpressv1alpha1.Wordpress) bool {
+	return options.BlueprintNamespace != "" && wp.Namespace == options.BlueprintNamespace
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(domain), ".")
+}
+
+func normalizePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	return path
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+var _ = Describe("Route domains", func() {
+	var wp, other *wordpressv1alpha1.Wordpress
+
+	BeforeEach(func() {
+		wp = &wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes: []wordpressv1alpha1.RouteSpec{
+					{Domain: "Example.com"},
+					{Domain: "example.com", Path: "/blog"},
+					{Domain: "www.example.com"},
+				},
+			},
+		}
+		other = &wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes: []wordpressv1alpha1.RouteSpec{
+					{Domain: "example.com", Path: "/"},
+				},
+			},
+		}
+	})
+
+	It("indexes the normalized domains of a site", func() {
+		Expect(routeDomains(wp)).To(Equal([]string{"example.com", "www.example.com"}))
+	})
+
+	It("rejects the routes claimed by other sites", func() {
+		errs := conflictingRoutes(wp, other, "example.com")
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.routes[0]"))
+		Expect(errs[0].Detail).To(ContainSubstring("other/other"))
+	})
+
+	It("allows sharing a domain on different paths", func() {
+		other.Spec.Routes[0].Path = "/shop"
+		Expect(conflictingRoutes(wp, other, "example.com")).To(BeEmpty())
+	})
+
+	It("ignores the site itself", func() {
+		Expect(conflictingRoutes(wp, wp.DeepCopy(), "example.com")).To(BeEmpty())
+	})
+
+	It("ignores the sites being deleted", func() {
+		now := metav1.Now()
+		other.DeletionTimestamp = &now
+		Expect(conflictingRoutes(wp, other, "example.com")).To(BeEmpty())
+	})
+
+	It("ignores the blueprint sites", func() {
+		options.BlueprintNamespace = "other"
+		defer func() { options.BlueprintNamespace = "" }()
+
+		Expect(conflictingRoutes(wp, other, "example.com")).To(BeEmpty())
+		Expect(routeDomains(other)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// Policy caps what a Wordpress spec may request. Zero values mean no limit.
+type Policy struct {
+	// MaxReplicas is the maximum number of web pods.
+	MaxReplicas int32
+	// MaxStorage is the maximum size of the volume claims.
+	MaxStorage resource.Quantity
+	// MaxResources are the maximum compute resources of the wordpress containers.
+	MaxResources corev1.ResourceList
+}
+
+// PolicyFromOptions returns the policy configured through the operator flags.
+func PolicyFromOptions() (*Policy, error) {
+	p := &Policy{
+		MaxReplicas:  options.MaxReplicas,
+		MaxResources: corev1.ResourceList{},
+	}
+
+	quantities := []struct {
+		flag  string
+		value string
+		into  func(resource.Quantity)
+	}{
+		{"max-storage", options.MaxStorage, func(q resource.Quantity) { p.MaxStorage = q }},
+		{"max-cpu", options.MaxCPU, func(q resource.Quantity) { p.MaxResources[corev1.ResourceCPU] = q }},
+		{"max-memory", options.MaxMemory, func(q resource.Quantity) { p.MaxResources[corev1.ResourceMemory] = q }},
+	}
+
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+
+		v, err := resource.ParseQuantity(q.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s value: %w", q.flag, err)
+		}
+
+		q.into(v)
+	}
+
+	return p, nil
+}
+
+// Validate returns the fields of the Wordpress spec exceeding the policy.
+func (p *Policy) Validate(wp *wordpressv1alpha1.Wordpress) field.ErrorList {
+	var errs field.ErrorList
+
+	spec := field.NewPath("spec")
+
+	if wp.Spec.Replicas != nil {
+		errs = append(errs, p.validateReplicas(*wp.Spec.Replicas, spec.Child("replicas"))...)
+	}
+
+	if wp.Spec.Autoscaling != nil {
+		autoscaling := spec.Child("autoscaling")
+
+		if keda := wp.Spec.Autoscaling.KEDA; keda != nil {
+			errs = append(errs, p.validateReplicas(keda.MaxReplicas, autoscaling.Child("keda", "maxReplicas"))...)
+		}
+
+		if vertical := wp.Spec.Autoscaling.Vertical; vertical != nil {
+			errs = append(errs, p.validateResourceList(vertical.MaxAllowed, autoscaling.Child("vertical", "maxAllowed"))...)
+		}
+	}
+
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil {
+		claim := &wp.Spec.CodeVolumeSpec.PersistentVolumeClaim.PersistentVolumeClaimSpec
+		errs = append(errs, p.validateClaim(claim, spec.Child("code", "persistentVolumeClaim"))...)
+	}
+
+	if wp.Spec.MediaVolumeSpec != nil && wp.Spec.MediaVolumeSpec.PersistentVolumeClaim != nil {
+		errs = append(errs, p.validateClaim(wp.Spec.MediaVolumeSpec.PersistentVolumeClaim, spec.Child("media", "persistentVolumeClaim"))...)
+	}
+
+	errs = append(errs, p.validateResources(&wp.Spec.Resources, spec.Child("resources"))...)
+
+	if wp.Spec.Components != nil {
+		components := spec.Child("components")
+
+		if web := wp.Spec.Components.Web; web != nil && web.Resources != nil {
+			errs = append(errs, p.validateResources(web.Resources, components.Child("web", "resources"))...)
+		}
+
+		if jobs := wp.Spec.Components.Jobs; jobs != nil && jobs.Resources != nil {
+			errs = append(errs, p.validateResources(jobs.Resources, components.Child("jobs", "resources"))...)
+		}
+
+		if cron := wp.Spec.Components.Cron; cron != nil && cron.Resources != nil {
+			errs = append(errs, p.validateResources(cron.Resources, components.Child("cron", "resources"))...)
+		}
+	}
+
+	return errs
+}
+
+func (p *Policy) validateReplicas(replicas int32, path *field.Path) field.ErrorList {
+	if p.MaxReplicas > 0 && replicas > p.MaxReplicas {
+		return field.ErrorList{field.Invalid(path, replicas, fmt.Sprintf("must be less than or equal to %d", p.MaxReplicas))}
+	}
+
+	return nil
+}
+
+func (p *Policy) validateClaim(claim *corev1.PersistentVolumeClaimSpec, path *field.Path) field.ErrorList {
+	storage, ok := claim.Resources.Requests[corev1.ResourceStorage]
+	if !ok || p.MaxStorage.IsZero() || storage.Cmp(p.MaxStorage) <= 0 {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(path.Child("resources", "requests", string(corev1.ResourceStorage)), storage.String(),
+			fmt.Sprintf("must be less than or equal to %s", p.MaxStorage.String())),
+	}
+}
+
+func (p *Policy) validateResources(resources *corev1.ResourceRequirements, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	errs = append(errs, p.validateResourceList(resources.Requests, path.Child("requests"))...)
+	errs = append(errs, p.validateResourceList(resources.Limits, path.Child("limits"))...)
+
+	return errs
+}
+
+func (p *Policy) validateResourceList(list corev1.ResourceList, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		limit, ok := p.MaxResources[name]
+		if !ok {
+			continue
+		}
+
+		if value, found := list[name]; found && value.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(path.Child(string(name)), value.String(),
+				fmt.Sprintf("must be less than or equal to %s", limit.String())))
+		}
+	}
+
+	return errs
+}
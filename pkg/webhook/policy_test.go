@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+var _ = Describe("Policy", func() {
+	var (
+		wp     *wordpressv1alpha1.Wordpress
+		policy *Policy
+	)
+
+	BeforeEach(func() {
+		wp = &wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "default",
+			},
+		}
+		policy = &Policy{
+			MaxReplicas: 3,
+			MaxStorage:  resource.MustParse("10Gi"),
+			MaxResources: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		}
+	})
+
+	It("allows sites within the limits", func() {
+		replicas := int32(3)
+		wp.Spec.Replicas = &replicas
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		}
+		wp.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}
+
+		Expect(policy.Validate(wp)).To(BeEmpty())
+	})
+
+	It("rejects too many replicas", func() {
+		replicas := int32(4)
+		wp.Spec.Replicas = &replicas
+		wp.Spec.Autoscaling = &wordpressv1alpha1.AutoscalingSpec{
+			KEDA: &wordpressv1alpha1.KEDAAutoscalingSpec{MaxReplicas: 5},
+		}
+
+		errs := policy.Validate(wp)
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Field).To(Equal("spec.replicas"))
+		Expect(errs[1].Field).To(Equal("spec.autoscaling.keda.maxReplicas"))
+	})
+
+	It("rejects too large volume claims", func() {
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			PersistentVolumeClaim: &wordpressv1alpha1.CodePersistentVolumeClaimSpec{
+				PersistentVolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("20Gi")},
+					},
+				},
+			},
+		}
+
+		errs := policy.Validate(wp)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.code.persistentVolumeClaim.resources.requests.storage"))
+	})
+
+	It("rejects too many compute resources", func() {
+		wp.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}
+		wp.Spec.Components = &wordpressv1alpha1.ComponentsSpec{
+			Jobs: &wordpressv1alpha1.ComponentSpec{
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				},
+			},
+			Cron: &wordpressv1alpha1.ComponentSpec{
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				},
+			},
+		}
+
+		errs := policy.Validate(wp)
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0].Field).To(Equal("spec.resources.requests.memory"))
+		Expect(errs[1].Field).To(Equal("spec.components.jobs.resources.limits.cpu"))
+		Expect(errs[2].Field).To(Equal("spec.components.cron.resources.limits.memory"))
+	})
+
+	It("does not limit anything by default", func() {
+		replicas := int32(100)
+		wp.Spec.Replicas = &replicas
+		wp.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Gi")}
+
+		p, err := PolicyFromOptions()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Validate(wp)).To(BeEmpty())
+	})
+
+	It("fails on invalid options", func() {
+		options.MaxCPU = "lots"
+		defer func() { options.MaxCPU = "" }()
+
+		_, err := PolicyFromOptions()
+		Expect(err).To(HaveOccurred())
+	})
+})
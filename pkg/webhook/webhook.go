@@ -0,0 +1,45 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+// AddToManager registers all the admission webhooks with the manager webhook server.
+func AddToManager(m manager.Manager) error {
+	policy, err := PolicyFromOptions()
+	if err != nil {
+		return err
+	}
+
+	// the sites are looked up by domain, to reject the ones claiming the routes of other sites
+	if err = m.GetFieldIndexer().IndexField(context.TODO(), &wordpressv1alpha1.Wordpress{}, routeDomainField, routeDomains); err != nil {
+		return err
+	}
+
+	m.GetWebhookServer().Register(ValidateWordpressPath, &admission.Webhook{
+		Handler: &wordpressValidator{policy: policy, client: m.GetClient()},
+	})
+
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+// ValidateWordpressPath is the path on which the Wordpress validating webhook is served.
+const ValidateWordpressPath = "/validate-wordpress"
+
+// +kubebuilder:webhook:path=/validate-wordpress,mutating=false,failurePolicy=fail,sideEffects=None,groups=wordpress.presslabs.org,resources=wordpresses,verbs=create;update,versions=v1alpha1,name=vwordpress.wordpress.presslabs.org,admissionReviewVersions=v1
+
+type wordpressValidator struct {
+	policy  *Policy
+	client  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &wordpressValidator{}
+var _ admission.DecoderInjector = &wordpressValidator{}
+
+// InjectDecoder injects the decoder.
+func (v *wordpressValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+
+	return nil
+}
+
+// Handle validates the created and updated Wordpress resources.
+func (v *wordpressValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	wp := &wordpressv1alpha1.Wordpress{}
+	if err := v.decoder.Decode(req, wp); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// the finalizers of the sites being deleted must remain removable
+	if wp.DeletionTimestamp != nil {
+		return admission.Allowed("")
+	}
+
+	errs, err := v.validate(ctx, wp)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// on update, only the new violations are rejected, so that the sites
+	// which became invalid, e.g. by lowering the limits, can still be updated
+	if len(errs) > 0 && req.Operation == admissionv1.Update {
+		old := &wordpressv1alpha1.Wordpress{}
+		if err = v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		var oldErrs field.ErrorList
+
+		if oldErrs, err = v.validate(ctx, old); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		errs = newErrors(errs, oldErrs)
+	}
+
+	if len(errs) > 0 {
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// validate returns the policy and the route domains violations of a site.
+func (v *wordpressValidator) validate(ctx context.Context, wp *wordpressv1alpha1.Wordpress) (field.ErrorList, error) {
+	errs := v.policy.Validate(wp)
+
+	domainErrs, err := v.validateDomains(ctx, wp)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(errs, domainErrs...), nil
+}
+
+// newErrors returns the errors which are not among the old ones.
+func newErrors(errs, old field.ErrorList) field.ErrorList {
+	seen := map[string]bool{}
+	for _, err := range old {
+		seen[err.Error()] = true
+	}
+
+	var out field.ErrorList
+
+	for _, err := range errs {
+		if !seen[err.Error()] {
+			out = append(out, err)
+		}
+	}
+
+	return out
+}
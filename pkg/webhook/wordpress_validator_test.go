@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/bitpoke/wordpress-operator/pkg/apis"
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+var _ = Describe("Wordpress validator", func() {
+	var (
+		validator *wordpressValidator
+		wp        *wordpressv1alpha1.Wordpress
+	)
+
+	raw := func(obj runtime.Object) runtime.RawExtension {
+		data, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+
+		return runtime.RawExtension{Raw: data}
+	}
+
+	request := func(op admissionv1.Operation, obj, old *wordpressv1alpha1.Wordpress) admission.Request {
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: op,
+			Object:    raw(obj),
+		}}
+		if old != nil {
+			req.OldObject = raw(old)
+		}
+
+		return req
+	}
+
+	BeforeEach(func() {
+		Expect(apis.AddToScheme(scheme.Scheme)).To(Succeed())
+
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+
+		validator = &wordpressValidator{
+			policy:  &Policy{MaxReplicas: 3},
+			decoder: decoder,
+		}
+
+		replicas := int32(5)
+		wp = &wordpressv1alpha1.Wordpress{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "wordpress.presslabs.org/v1alpha1", Kind: "Wordpress"},
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec:       wordpressv1alpha1.WordpressSpec{Replicas: &replicas},
+		}
+	})
+
+	It("rejects the sites violating the policy", func() {
+		resp := validator.Handle(context.TODO(), request(admissionv1.Create, wp, nil))
+		Expect(resp.Allowed).To(BeFalse())
+	})
+
+	It("allows updating sites which were already violating the policy", func() {
+		old := wp.DeepCopy()
+		wp.Finalizers = []string{"wordpress.presslabs.org/test"}
+
+		resp := validator.Handle(context.TODO(), request(admissionv1.Update, wp, old))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("rejects updates introducing new violations", func() {
+		old := wp.DeepCopy()
+		replicas := int32(6)
+		wp.Spec.Replicas = &replicas
+
+		resp := validator.Handle(context.TODO(), request(admissionv1.Update, wp, old))
+		Expect(resp.Allowed).To(BeFalse())
+	})
+
+	It("allows updating the sites being deleted", func() {
+		old := wp.DeepCopy()
+		now := metav1.Now()
+		wp.DeletionTimestamp = &now
+
+		resp := validator.Handle(context.TODO(), request(admissionv1.Update, wp, old))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+})